@@ -0,0 +1,351 @@
+package frog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	// ErrNoBackend is returned when a balancing channel has no backend to pick.
+	ErrNoBackend = errors.New("frog: no backend available")
+)
+
+// Dialer creates a RpcChannel to a single backend address.
+type Dialer func(addr string) (RpcChannel, error)
+
+// HealthCheckFunc actively checks the health of a backend through its sub-channel.
+type HealthCheckFunc func(ctx context.Context, channel RpcChannel) error
+
+// BalancePolicy decides which backend serves a call.
+// Implementations will be called by many goroutines simultaneously.
+type BalancePolicy interface {
+	// Update is called with the current set of backend addresses, sorted.
+	Update(addrs []string)
+
+	// Pick chooses the backend address for a call. If done is not nil,
+	// it is invoked with the call's error once the call completes, and
+	// should not block.
+	Pick(method *MethodDesc, ctx context.Context, request proto.Message) (addr string, done func(err error), err error)
+}
+
+// BalancingChannel implements RpcChannel by spreading calls over
+// sub-channels, one per backend address, according to a BalancePolicy.
+type BalancingChannel struct {
+	dial   Dialer
+	policy BalancePolicy
+
+	updateMu sync.Mutex // serializes Update, held while dialing
+
+	mu        sync.RWMutex // protect following fields
+	subs      map[string]RpcChannel
+	unhealthy map[string]bool
+	watcher   Watcher
+	closed    bool
+	stopCheck chan struct{}
+}
+
+// NewBalancingChannel creates a balancing channel which dials backends with dial
+// and picks them with policy. A nil policy means round robin.
+func NewBalancingChannel(dial Dialer, policy BalancePolicy, addrs ...string) (*BalancingChannel, error) {
+	if policy == nil {
+		policy = NewRoundRobinPolicy()
+	}
+	bc := &BalancingChannel{
+		dial:      dial,
+		policy:    policy,
+		subs:      make(map[string]RpcChannel),
+		unhealthy: make(map[string]bool),
+	}
+	err := bc.Update(addrs)
+	return bc, err
+}
+
+// Update replaces the backend set. New addresses are dialed, and sub-channels
+// of removed addresses are closed if they implement io.Closer.
+// Addresses failed to dial are left out and reported in the returned error.
+// Calls go on with the previous backends while new ones are dialed.
+func (bc *BalancingChannel) Update(addrs []string) error {
+	bc.updateMu.Lock()
+	defer bc.updateMu.Unlock()
+
+	// find the new addresses
+	bc.mu.RLock()
+	if bc.closed {
+		bc.mu.RUnlock()
+		return ErrNoBackend
+	}
+	var added []string
+	wanted := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		if wanted[addr] {
+			continue
+		}
+		wanted[addr] = true
+		if _, ok := bc.subs[addr]; !ok {
+			added = append(added, addr)
+		}
+	}
+	bc.mu.RUnlock()
+
+	var firstErr error
+	dialed := make(map[string]RpcChannel, len(added))
+	for _, addr := range added {
+		sub, err := bc.dial(addr)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("frog: dial %s failed: %v", addr, err)
+			}
+			continue
+		}
+		dialed[addr] = sub
+	}
+
+	var removed []RpcChannel
+	bc.mu.Lock()
+	if bc.closed {
+		// closed while dialing
+		bc.mu.Unlock()
+		for _, sub := range dialed {
+			closeChannel(sub)
+		}
+		return ErrNoBackend
+	}
+	for addr, sub := range dialed {
+		bc.subs[addr] = sub
+	}
+	for addr, sub := range bc.subs {
+		if !wanted[addr] {
+			delete(bc.subs, addr)
+			delete(bc.unhealthy, addr)
+			removed = append(removed, sub)
+		}
+	}
+	bc.updatePolicy()
+	bc.mu.Unlock()
+
+	for _, sub := range removed {
+		closeChannel(sub)
+	}
+	return firstErr
+}
+
+// closeChannel closes sub if it implements io.Closer.
+func closeChannel(sub RpcChannel) {
+	if c, ok := sub.(io.Closer); ok {
+		c.Close()
+	}
+}
+
+// updatePolicy passes the healthy backends to the policy, or all backends
+// if none is healthy. bc.mu must be held.
+func (bc *BalancingChannel) updatePolicy() {
+	all := make([]string, 0, len(bc.subs))
+	healthy := make([]string, 0, len(bc.subs))
+	for addr := range bc.subs {
+		all = append(all, addr)
+		if !bc.unhealthy[addr] {
+			healthy = append(healthy, addr)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy = all
+	}
+	sort.Strings(healthy)
+	bc.policy.Update(healthy)
+}
+
+// EnableHealthCheck checks every backend with check each interval and routes
+// calls to healthy backends only. Each check runs with interval as timeout.
+func (bc *BalancingChannel) EnableHealthCheck(check HealthCheckFunc, interval time.Duration) {
+	stop := make(chan struct{})
+	bc.mu.Lock()
+	if bc.closed || bc.stopCheck != nil {
+		bc.mu.Unlock()
+		return
+	}
+	bc.stopCheck = stop
+	bc.mu.Unlock()
+
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			bc.checkHealth(check, interval)
+			select {
+			case <-stop:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+func (bc *BalancingChannel) checkHealth(check HealthCheckFunc, timeout time.Duration) {
+	bc.mu.RLock()
+	subs := make(map[string]RpcChannel, len(bc.subs))
+	for addr, sub := range bc.subs {
+		subs[addr] = sub
+	}
+	bc.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	unhealthy := make(map[string]bool)
+	for addr, sub := range subs {
+		wg.Add(1)
+		go func(addr string, sub RpcChannel) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := check(ctx, sub); err != nil {
+				mu.Lock()
+				unhealthy[addr] = true
+				mu.Unlock()
+			}
+		}(addr, sub)
+	}
+	wg.Wait()
+
+	bc.mu.Lock()
+	changed := false
+	for addr := range bc.subs {
+		if _, checked := subs[addr]; checked && bc.unhealthy[addr] != unhealthy[addr] {
+			changed = true
+			if unhealthy[addr] {
+				bc.unhealthy[addr] = true
+			} else {
+				delete(bc.unhealthy, addr)
+			}
+		}
+	}
+	if changed && !bc.closed {
+		bc.updatePolicy()
+	}
+	bc.mu.Unlock()
+}
+
+// watch keeps the backend set in line with w until the channel is closed.
+func (bc *BalancingChannel) watch(w Watcher) {
+	bc.mu.Lock()
+	bc.watcher = w
+	bc.mu.Unlock()
+
+	go func() {
+		for {
+			addrs, err := w.Next()
+			if err == ErrWatcherClosed {
+				return
+			}
+			if err != nil {
+				// a broken watcher should not spin
+				time.Sleep(time.Second)
+				continue
+			}
+			bc.Update(addrs)
+		}
+	}()
+}
+
+// Backends returns the addresses currently in use, sorted.
+func (bc *BalancingChannel) Backends() []string {
+	bc.mu.RLock()
+	addrs := make([]string, 0, len(bc.subs))
+	for addr := range bc.subs {
+		addrs = append(addrs, addr)
+	}
+	bc.mu.RUnlock()
+	sort.Strings(addrs)
+	return addrs
+}
+
+// Go picks a backend and invokes the method on its sub-channel.
+func (bc *BalancingChannel) Go(method *MethodDesc, ctx context.Context, request proto.Message, response proto.Message) RpcCall {
+	addr, done, err := bc.policy.Pick(method, ctx, request)
+	if err != nil {
+		return failedCall(request, response, err)
+	}
+
+	bc.mu.RLock()
+	sub := bc.subs[addr]
+	bc.mu.RUnlock()
+	if sub == nil {
+		err = fmt.Errorf("frog: backend %s is gone", addr)
+		if done != nil {
+			done(err)
+		}
+		return failedCall(request, response, err)
+	}
+
+	call := sub.Go(method, ctx, request, response)
+	if done != nil {
+		OnDone(call, func(call RpcCall) { done(call.Error()) })
+	}
+	return call
+}
+
+// Close stops watching the target and health checks, if any, and closes the
+// policy and all sub-channels which implement io.Closer.
+func (bc *BalancingChannel) Close() error {
+	bc.mu.Lock()
+	subs := bc.subs
+	bc.subs = make(map[string]RpcChannel)
+	bc.policy.Update(nil)
+	w := bc.watcher
+	bc.watcher = nil
+	bc.closed = true
+	if bc.stopCheck != nil {
+		close(bc.stopCheck)
+	}
+	bc.mu.Unlock()
+
+	if w != nil {
+		w.Close()
+	}
+	if c, ok := bc.policy.(io.Closer); ok {
+		c.Close()
+	}
+
+	for _, sub := range subs {
+		closeChannel(sub)
+	}
+	return nil
+}
+
+func failedCall(request, response proto.Message, err error) RpcCall {
+	call := NewDefaultCall(request, response)
+	call.Close(err)
+	return call
+}
+
+// RoundRobinPolicy picks backends in turn.
+type RoundRobinPolicy struct {
+	next  uint64
+	addrs atomic.Value // []string
+}
+
+func NewRoundRobinPolicy() *RoundRobinPolicy {
+	p := new(RoundRobinPolicy)
+	p.addrs.Store([]string(nil))
+	return p
+}
+
+func (p *RoundRobinPolicy) Update(addrs []string) {
+	p.addrs.Store(append([]string(nil), addrs...))
+}
+
+func (p *RoundRobinPolicy) Pick(method *MethodDesc, ctx context.Context, request proto.Message) (string, func(error), error) {
+	addrs := p.addrs.Load().([]string)
+	if len(addrs) == 0 {
+		return "", nil, ErrNoBackend
+	}
+	n := atomic.AddUint64(&p.next, 1)
+	return addrs[n%uint64(len(addrs))], nil, nil
+}
@@ -0,0 +1,72 @@
+package frog
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestBalancingChannelDialsUnlocked(t *testing.T) {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	dialing := make(chan struct{})
+	unblock := make(chan struct{})
+	dial := func(addr string) (RpcChannel, error) {
+		if addr == "slow" {
+			close(dialing)
+			<-unblock
+		}
+		return &countingChannel{addr, &mu, counts}, nil
+	}
+	bc, err := NewBalancingChannel(dial, nil, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Close()
+
+	updated := make(chan error, 1)
+	go func() { updated <- bc.Update([]string{"a", "slow"}) }()
+	<-dialing
+
+	// calls and backends are served while a backend is dialed
+	inTime(t, "call during a slow dial", func() {
+		<-bc.Go(testService.Method(testEcho), context.Background(), nil, nil).Done()
+		if got := bc.Backends(); !reflect.DeepEqual(got, []string{"a"}) {
+			t.Errorf("backends during the dial = %v, want [a]", got)
+		}
+	})
+	close(unblock)
+	if err := <-updated; err != nil {
+		t.Fatal(err)
+	}
+	if got := bc.Backends(); !reflect.DeepEqual(got, []string{"a", "slow"}) {
+		t.Errorf("backends = %v, want [a slow]", got)
+	}
+}
+
+func TestBalancingChannelClosedWhileDialing(t *testing.T) {
+	closed := make(chan string, 1)
+	dialing := make(chan struct{})
+	unblock := make(chan struct{})
+	dial := func(addr string) (RpcChannel, error) {
+		if addr == "slow" {
+			close(dialing)
+			<-unblock
+		}
+		return &closingChannel{countingChannel{addr, new(sync.Mutex), make(map[string]int)}, closed}, nil
+	}
+	bc, _ := NewBalancingChannel(dial, nil)
+
+	updated := make(chan error, 1)
+	go func() { updated <- bc.Update([]string{"slow"}) }()
+	<-dialing
+	bc.Close()
+	close(unblock)
+	if err := <-updated; err != ErrNoBackend {
+		t.Errorf("Update of a closed channel = %v, want ErrNoBackend", err)
+	}
+	if addr := <-closed; addr != "slow" {
+		t.Errorf("closed %s, want the channel dialed after Close", addr)
+	}
+}
@@ -0,0 +1,761 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yplusplus/frog"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Packages used by the generated code.
+const (
+	contextPackage = protogen.GoImportPath("context")
+	protoPackage   = protogen.GoImportPath("google.golang.org/protobuf/proto")
+	frogPackage    = protogen.GoImportPath("github.com/yplusplus/frog")
+)
+
+// generator generates the frog code of one file.
+type generator struct {
+	*protogen.GeneratedFile
+}
+
+// generateFile generates a _frog.pb.go file containing frog service definitions.
+func generateFile(gen *protogen.Plugin, file *protogen.File, mock bool) *protogen.GeneratedFile {
+	if len(file.Services) == 0 {
+		return nil
+	}
+	filename := file.GeneratedFilenamePrefix + "_frog.pb.go"
+	g := &generator{gen.NewGeneratedFile(filename, file.GoImportPath)}
+	g.P("// Code generated by protoc-gen-go-frog. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, service := range file.Services {
+		g.generateService(service)
+		if mock {
+			g.P()
+			g.generateMock(service)
+		}
+	}
+
+	// The descriptor of the file is built by the init function of the
+	// message code in x.pb.go. The service descriptors call it first, as
+	// protoc-gen-go does for the files a file imports, so they do not depend
+	// on the order of the files and are set before any init function runs.
+	descFunc := unexport(file.GoDescriptorIdent.GoName) + "_serviceDesc"
+	g.P("var (")
+	for _, service := range file.Services {
+		g.P(serviceDescName(service), " = ", descFunc, "(", strconv.Quote(string(service.Desc.FullName())), ")")
+	}
+	g.P(")")
+	g.P()
+	g.P("func ", descFunc, "(name string) *", g.frog("ServiceDesc"), " {")
+	g.P(unexport(file.GoDescriptorIdent.GoName), "_init()")
+	g.P(g.frog("GenerateServiceDescFromFile"), "(", file.GoDescriptorIdent, ")")
+	g.P("return ", g.frog("ServiceDescriptor"), "(name)")
+	g.P("}")
+	return g.GeneratedFile
+}
+
+func (g *generator) frog(name string) string {
+	return g.QualifiedGoIdent(frogPackage.Ident(name))
+}
+
+func (g *generator) context() string {
+	return g.QualifiedGoIdent(contextPackage.Ident("Context"))
+}
+
+func (g *generator) message() string {
+	return g.QualifiedGoIdent(protoPackage.Ident("Message"))
+}
+
+func (g *generator) typeName(message *protogen.Message) string {
+	return g.QualifiedGoIdent(message.GoIdent)
+}
+
+func serviceDescName(service *protogen.Service) string {
+	return frog.GenerateServiceDescName(service.GoName)
+}
+
+func methodDescName(service *protogen.Service, method *protogen.Method) string {
+	return frog.GenerateMethodDescName(service.GoName, method.GoName)
+}
+
+func unexport(s string) string { return strings.ToLower(s[:1]) + s[1:] }
+
+func isStreaming(method *protogen.Method) bool {
+	return method.Desc.IsStreamingServer() || method.Desc.IsStreamingClient()
+}
+
+// deprecationComment is the line marking deprecated identifiers.
+const deprecationComment = " Deprecated: Do not use."
+
+// comments returns the lines of the leading and trailing comments of an
+// element, followed by a deprecation notice if deprecated. Paragraphs are
+// separated by empty lines.
+func comments(set protogen.CommentSet, deprecated bool) []string {
+	var lines []string
+	add := func(paragraph ...string) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, paragraph...)
+	}
+	for _, c := range []protogen.Comments{set.Leading, set.Trailing} {
+		if c != "" {
+			add(strings.Split(strings.TrimSuffix(string(c), "\n"), "\n")...)
+		}
+	}
+	if deprecated {
+		add(deprecationComment)
+	}
+	return lines
+}
+
+func isDeprecated(desc protoreflect.Descriptor) bool {
+	opts, ok := desc.Options().(interface{ GetDeprecated() bool })
+	return ok && opts.GetDeprecated()
+}
+
+// asyncDoc returns the header of the doc comment of the AsyncXxx variant of a method.
+func asyncDoc(methName string) string {
+	return "Async" + methName + " is the asynchronous version of " + methName + "."
+}
+
+// printDoc prints a doc comment made of the header line, if any, and lines.
+func (g *generator) printDoc(header string, lines []string) {
+	if header != "" {
+		g.P("// ", header)
+		if len(lines) > 0 {
+			g.P("//")
+		}
+	}
+	for _, line := range lines {
+		g.P("//", line)
+	}
+}
+
+// generateService generates all the code for the service.
+func (g *generator) generateService(service *protogen.Service) {
+	servName := service.GoName
+	stubName := unexport(servName) + "Stub"
+
+	servDeprecated := isDeprecated(service.Desc)
+	servDoc := comments(service.Comments, servDeprecated)
+	methDocs := make([][]string, len(service.Methods))
+	for i, method := range service.Methods {
+		methDocs[i] = comments(method.Comments, isDeprecated(method.Desc))
+	}
+
+	g.P("// Stub for ", servName, " service")
+	g.P()
+
+	// Method names and descriptors.
+	g.P("// Full names of the methods of ", servName, " service, as returned by MethodDesc.FullName.")
+	g.P("const (")
+	for _, method := range service.Methods {
+		name := "/" + string(service.Desc.FullName()) + "/" + string(method.Desc.Name())
+		g.P(frog.GenerateFullMethodNameConst(servName, method.GoName), " = ", strconv.Quote(name))
+	}
+	g.P(")")
+	g.P()
+	for index, method := range service.Methods {
+		g.P("// ", methodDescName(service, method), " returns the descriptor of the ", method.GoName, " method of ", servName, " service.")
+		g.P("func ", methodDescName(service, method), "() *", g.frog("MethodDesc"), " {")
+		g.P("return ", serviceDescName(service), ".Method(", index, ")")
+		g.P("}")
+		g.P()
+	}
+
+	// Client interface.
+	g.printDoc(servName+"Client is the client API for "+servName+" service.", servDoc)
+	g.P("type ", servName, "Client interface {")
+	for i, method := range service.Methods {
+		g.printDoc("", methDocs[i])
+		if isStreaming(method) {
+			g.P(g.stubStreamSignature(servName, method))
+			continue
+		}
+		g.P(g.stubSignature(method))
+		g.printDoc(asyncDoc(method.GoName), methDocs[i])
+		g.P(g.stubAsyncSignature(method))
+	}
+	g.P("}")
+	g.P()
+
+	// Stub structure.
+	g.P("type ", stubName, " struct {")
+	g.P("channel ", g.frog("RpcChannel"))
+	g.P("}")
+	g.P()
+
+	// NewStub factory.
+	g.printDoc("New"+servName+"Stub returns the "+servName+"Client calling methods on channel.", servDoc)
+	g.P("func New", servName, "Stub(channel ", g.frog("RpcChannel"), ") ", servName, "Client {")
+	g.P("return &", stubName, "{channel}")
+	g.P("}")
+	g.P()
+
+	// Stub method implementations.
+	for index, method := range service.Methods {
+		deprecated := servDeprecated || isDeprecated(method.Desc)
+		g.generateStubMethod(service, method, methDocs[index], deprecated)
+	}
+
+	// Stub call method and go method
+	g.P("func (stub *", stubName, ") Call(method *", g.frog("MethodDesc"), ", ctx ", g.context(), ", in ", g.message(), ", out ", g.message(), ") error {")
+	g.P("call := stub.channel.Go(method, ctx, in, out)")
+	g.P("<-call.Done()")
+	g.P("return call.Error()")
+	g.P("}")
+	g.P()
+
+	g.P("func (stub *", stubName, ") Go(method *", g.frog("MethodDesc"), ", ctx ", g.context(), ", in ", g.message(), ", out ", g.message(), ") ", g.frog("RpcCall"), " {")
+	g.P("return stub.channel.Go(method, ctx, in, out)")
+	g.P("}")
+	g.P()
+
+	// Server interface.
+	g.printDoc(servName+" is the server API for "+servName+" service.", servDoc)
+	g.P("type ", servName, " interface {")
+	for i, method := range service.Methods {
+		g.printDoc("", methDocs[i])
+		g.P(g.serverSignature(servName, method))
+	}
+	g.P("}")
+	g.P()
+
+	// Unimplemented base structure.
+	g.P("// Unimplemented", servName, " should be embedded by implementations of ", servName, ",")
+	g.P("// so they keep compiling and registering when methods are added to the service.")
+	g.P("type Unimplemented", servName, " struct{}")
+	g.P()
+	for _, method := range service.Methods {
+		g.P("func (Unimplemented", servName, ") ", g.serverMethodSignature(servName, method), " {")
+		g.P("return ", g.frog("Errorf"), "(", g.frog("Unimplemented"), ", \"method ", method.GoName, " not implemented\")")
+		g.P("}")
+		g.P()
+	}
+
+	g.P("func Register", servName, "(service ", servName, ", register ", g.frog("MethodsRegister"), ") error {")
+	g.P("return ", g.frog("RegisterServiceHandlers"), "(", serviceDescName(service), ", service, register, _", servName, "_Handlers)")
+	g.P("}")
+	g.P()
+
+	g.generateHandlers(service)
+	g.generateAsyncService(service, servDoc, methDocs)
+}
+
+// generateAsyncService generates the server API of the service served
+// asynchronously, whose unary methods take a frog.RpcController, and its
+// handlers.
+func (g *generator) generateAsyncService(service *protogen.Service, servDoc []string, methDocs [][]string) {
+	servName := service.GoName
+	asyncName := servName + "Async"
+
+	g.P()
+	g.printDoc(asyncName+" is the server API for "+servName+" service served asynchronously:", nil)
+	g.P("// unary methods may return at once and complete the call later with ctrl.Done.")
+	if len(servDoc) > 0 {
+		g.P("//")
+		g.printDoc("", servDoc)
+	}
+	g.P("type ", asyncName, " interface {")
+	for i, method := range service.Methods {
+		g.printDoc("", methDocs[i])
+		g.P(g.asyncServerSignature(servName, method))
+	}
+	g.P("}")
+	g.P()
+
+	// Unimplemented base structure.
+	g.P("// Unimplemented", asyncName, " should be embedded by implementations of ", asyncName, ",")
+	g.P("// so they keep compiling and registering when methods are added to the service.")
+	g.P("type Unimplemented", asyncName, " struct{}")
+	g.P()
+	for _, method := range service.Methods {
+		unimplemented := g.frog("Errorf") + "(" + g.frog("Unimplemented") + ", \"method " + method.GoName + " not implemented\")"
+		g.P("func (Unimplemented", asyncName, ") ", g.asyncServerMethodSignature(servName, method), " {")
+		if isStreaming(method) {
+			g.P("return ", unimplemented)
+		} else {
+			g.P("ctrl.Done(", unimplemented, ")")
+		}
+		g.P("}")
+		g.P()
+	}
+
+	g.P("func Register", asyncName, "(service ", asyncName, ", register ", g.frog("MethodsRegister"), ") error {")
+	g.P("return ", g.frog("RegisterServiceHandlers"), "(", serviceDescName(service), ", service, register, _", asyncName, "_Handlers)")
+	g.P("}")
+	g.P()
+
+	g.P("var _", asyncName, "_Handlers = &", g.frog("ServiceHandlers"), "{")
+	g.P("AsyncDispatch: _", asyncName, "_Dispatch,")
+	g.P("NewRequest: _", servName, "_NewRequest,")
+	g.P("NewResponse: _", servName, "_NewResponse,")
+	g.generateStreams(service, asyncName)
+	g.P("}")
+	g.P()
+
+	// Dispatch calls the unary methods.
+	g.P("func _", asyncName, "_Dispatch(service interface{}, index int, ctrl ", g.frog("RpcController"), ", in ", g.message(), ", out ", g.message(), ") {")
+	g.P("switch index {")
+	for i, method := range service.Methods {
+		if isStreaming(method) {
+			continue
+		}
+		g.P("case ", i, ":")
+		g.P("service.(", asyncName, ").", method.GoName, "(ctrl, in.(*", g.typeName(method.Input), "), out.(*", g.typeName(method.Output), "))")
+		g.P("return")
+	}
+	g.P("}")
+	g.P("ctrl.Done(", g.frog("Errorf"), "(", g.frog("Unimplemented"), ", \"", servName, " has no unary method %d\", index))")
+	g.P("}")
+
+	for _, method := range service.Methods {
+		if isStreaming(method) {
+			g.P()
+			g.generateStreamHandler(servName, asyncName, method)
+		}
+	}
+}
+
+// generateStreams generates the Streams field of the handlers of service,
+// whose stream handlers are named after prefix.
+func (g *generator) generateStreams(service *protogen.Service, prefix string) {
+	hasStreams := false
+	for _, method := range service.Methods {
+		if isStreaming(method) {
+			hasStreams = true
+		}
+	}
+	if !hasStreams {
+		return
+	}
+	g.P("Streams: []", g.frog("StreamHandler"), "{")
+	for _, method := range service.Methods {
+		if isStreaming(method) {
+			g.P("_", prefix, "_", method.GoName, "_Handler,")
+		} else {
+			g.P("nil,")
+		}
+	}
+	g.P("},")
+}
+
+// generateHandlers generates the handlers serving the service without reflection.
+func (g *generator) generateHandlers(service *protogen.Service) {
+	servName := service.GoName
+	g.P("var _", servName, "_Handlers = &", g.frog("ServiceHandlers"), "{")
+	g.P("Dispatch: _", servName, "_Dispatch,")
+	g.P("NewRequest: _", servName, "_NewRequest,")
+	g.P("NewResponse: _", servName, "_NewResponse,")
+	g.generateStreams(service, servName)
+	g.P("}")
+	g.P()
+
+	// Dispatch calls the unary methods.
+	g.P("func _", servName, "_Dispatch(service interface{}, index int, ctx ", g.context(), ", in ", g.message(), ", out ", g.message(), ") error {")
+	g.P("switch index {")
+	for i, method := range service.Methods {
+		if isStreaming(method) {
+			continue
+		}
+		g.P("case ", i, ":")
+		g.P("return service.(", servName, ").", method.GoName, "(ctx, in.(*", g.typeName(method.Input), "), out.(*", g.typeName(method.Output), "))")
+	}
+	g.P("}")
+	g.P("return ", g.frog("Errorf"), "(", g.frog("Unimplemented"), ", \"", servName, " has no unary method %d\", index)")
+	g.P("}")
+	g.P()
+
+	// Message factories.
+	g.P("func _", servName, "_NewRequest(index int) ", g.message(), " {")
+	g.P("switch index {")
+	for i, method := range service.Methods {
+		g.P("case ", i, ":")
+		g.P("return new(", g.typeName(method.Input), ")")
+	}
+	g.P("}")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+
+	g.P("func _", servName, "_NewResponse(index int) ", g.message(), " {")
+	g.P("switch index {")
+	for i, method := range service.Methods {
+		g.P("case ", i, ":")
+		g.P("return new(", g.typeName(method.Output), ")")
+	}
+	g.P("}")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+
+	for _, method := range service.Methods {
+		if isStreaming(method) {
+			g.generateServerStream(servName, method)
+		}
+	}
+}
+
+// generateStubMethod generates the stub methods of a method, documented by
+// doc. Stubs of deprecated methods notify the frog deprecation handler.
+func (g *generator) generateStubMethod(service *protogen.Service, method *protogen.Method, doc []string, deprecated bool) {
+	if isStreaming(method) {
+		g.generateStubStream(service, method, doc, deprecated)
+		return
+	}
+
+	stubName := unexport(service.GoName) + "Stub"
+	methDesc := methodDescName(service, method) + "()"
+	// sync method
+	g.printDoc("", doc)
+	g.P("func (stub *", stubName, ") ", g.stubSignature(method), " {")
+	g.notifyDeprecated(methDesc, deprecated)
+	g.P("return stub.Call(", methDesc, ", ctx, in, out)")
+	g.P("}")
+	g.P()
+
+	// async method
+	g.printDoc(asyncDoc(method.GoName), doc)
+	g.P("func (stub *", stubName, ") ", g.stubAsyncSignature(method), " {")
+	g.notifyDeprecated(methDesc, deprecated)
+	g.P("return ", g.frog("NewTypedCall"), "(stub.Go(", methDesc, ", ctx, in, out), out)")
+	g.P("}")
+	g.P()
+}
+
+// notifyDeprecated generates the notification of a call to a deprecated method.
+func (g *generator) notifyDeprecated(methDesc string, deprecated bool) {
+	if deprecated {
+		g.P(g.frog("NotifyDeprecated"), "(", methDesc, ")")
+	}
+}
+
+// generateStubStream generates the stub method of a streaming method,
+// and its typed client stream.
+func (g *generator) generateStubStream(service *protogen.Service, method *protogen.Method, doc []string, deprecated bool) {
+	servName := service.GoName
+	methName := method.GoName
+	inType := g.typeName(method.Input)
+	outType := g.typeName(method.Output)
+	streamType := unexport(servName) + methName + "Client"
+
+	g.printDoc("", doc)
+	g.P("func (stub *", unexport(servName), "Stub) ", g.stubStreamSignature(servName, method), " {")
+	methDesc := methodDescName(service, method) + "()"
+	g.notifyDeprecated(methDesc, deprecated)
+	g.P("stream, err := ", g.frog("NewStream"), "(stub.channel, ", methDesc, ", ctx)")
+	g.P("if err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("x := &", streamType, "{stream}")
+	if !method.Desc.IsStreamingClient() {
+		g.P("if err := x.ClientStream.SendMsg(in); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("if err := x.ClientStream.CloseSend(); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+	}
+	g.P("return x, nil")
+	g.P("}")
+	g.P()
+
+	g.P("type ", servName, "_", methName, "Client interface {")
+	if method.Desc.IsStreamingClient() {
+		g.P("Send(*", inType, ") error")
+	}
+	if method.Desc.IsStreamingServer() {
+		g.P("Recv() (*", outType, ", error)")
+	} else {
+		g.P("CloseAndRecv() (*", outType, ", error)")
+	}
+	g.P(g.frog("ClientStream"))
+	g.P("}")
+	g.P()
+
+	g.P("type ", streamType, " struct {")
+	g.P(g.frog("ClientStream"))
+	g.P("}")
+	g.P()
+
+	if method.Desc.IsStreamingClient() {
+		g.P("func (x *", streamType, ") Send(m *", inType, ") error {")
+		g.P("return x.ClientStream.SendMsg(m)")
+		g.P("}")
+		g.P()
+	}
+
+	if method.Desc.IsStreamingServer() {
+		g.P("func (x *", streamType, ") Recv() (*", outType, ", error) {")
+	} else {
+		g.P("func (x *", streamType, ") CloseAndRecv() (*", outType, ", error) {")
+		g.P("if err := x.ClientStream.CloseSend(); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+	}
+	g.P("m := new(", outType, ")")
+	g.P("if err := x.ClientStream.RecvMsg(m); err != nil {")
+	g.P("return nil, err")
+	g.P("}")
+	g.P("return m, nil")
+	g.P("}")
+	g.P()
+}
+
+// generateStreamHandler generates the handler of a streaming method of
+// the server API iface.
+func (g *generator) generateStreamHandler(servName, iface string, method *protogen.Method) {
+	methName := method.GoName
+	streamType := unexport(servName) + methName + "Server"
+
+	g.P("func _", iface, "_", methName, "_Handler(service interface{}, stream ", g.frog("ServerStream"), ") error {")
+	if method.Desc.IsStreamingClient() {
+		g.P("return service.(", iface, ").", methName, "(stream.Context(), &", streamType, "{stream})")
+	} else {
+		g.P("in := new(", g.typeName(method.Input), ")")
+		g.P("if err := stream.RecvMsg(in); err != nil {")
+		g.P("return err")
+		g.P("}")
+		g.P("return service.(", iface, ").", methName, "(stream.Context(), in, &", streamType, "{stream})")
+	}
+	g.P("}")
+}
+
+// generateServerStream generates the handler of a streaming method,
+// and its typed server stream.
+func (g *generator) generateServerStream(servName string, method *protogen.Method) {
+	methName := method.GoName
+	inType := g.typeName(method.Input)
+	outType := g.typeName(method.Output)
+	streamType := unexport(servName) + methName + "Server"
+
+	g.generateStreamHandler(servName, servName, method)
+	g.P()
+
+	g.P("type ", servName, "_", methName, "Server interface {")
+	if method.Desc.IsStreamingServer() {
+		g.P("Send(*", outType, ") error")
+	} else {
+		g.P("SendAndClose(*", outType, ") error")
+	}
+	if method.Desc.IsStreamingClient() {
+		g.P("Recv() (*", inType, ", error)")
+	}
+	g.P(g.frog("ServerStream"))
+	g.P("}")
+	g.P()
+
+	g.P("type ", streamType, " struct {")
+	g.P(g.frog("ServerStream"))
+	g.P("}")
+	g.P()
+
+	if method.Desc.IsStreamingServer() {
+		g.P("func (x *", streamType, ") Send(m *", outType, ") error {")
+	} else {
+		g.P("func (x *", streamType, ") SendAndClose(m *", outType, ") error {")
+	}
+	g.P("return x.ServerStream.SendMsg(m)")
+	g.P("}")
+	g.P()
+
+	if method.Desc.IsStreamingClient() {
+		g.P("func (x *", streamType, ") Recv() (*", inType, ", error) {")
+		g.P("m := new(", inType, ")")
+		g.P("if err := x.ServerStream.RecvMsg(m); err != nil {")
+		g.P("return nil, err")
+		g.P("}")
+		g.P("return m, nil")
+		g.P("}")
+		g.P()
+	}
+}
+
+// generateMock generates mock implementations of the server and client interfaces.
+func (g *generator) generateMock(service *protogen.Service) {
+	servName := service.GoName
+	unimplemented := func(mock, methName string) string {
+		return fmt.Sprintf("%s(%s, \"%s.%s has no hook\")", g.frog("Errorf"), g.frog("Unimplemented"), mock, methName)
+	}
+
+	// Server mock.
+	mock := "Mock" + servName
+	g.P("// ", mock, " is a mock implementation of ", servName, ".")
+	g.P("// Methods without a hook fail with Unimplemented.")
+	g.P("type ", mock, " struct {")
+	g.P(g.frog("MockRecorder"))
+	g.P("}")
+	g.P()
+	g.P("var _ ", servName, " = (*", mock, ")(nil)")
+	g.P()
+	for _, method := range service.Methods {
+		methName := method.GoName
+		inType := g.typeName(method.Input)
+		outType := g.typeName(method.Output)
+		streamType := servName + "_" + methName + "Server"
+
+		var hookType, call, in, out string
+		switch {
+		case method.Desc.IsStreamingClient():
+			hookType = "func(" + g.context() + ", " + streamType + ") error"
+			call, in, out = "fn(ctx, stream)", "nil", "nil"
+		case method.Desc.IsStreamingServer():
+			hookType = "func(" + g.context() + ", *" + inType + ", " + streamType + ") error"
+			call, in, out = "fn(ctx, in, stream)", "in", "nil"
+		default:
+			hookType = "func(" + g.context() + ", *" + inType + ", *" + outType + ") error"
+			call, in, out = "fn(ctx, in, out)", "in", "out"
+		}
+
+		g.P("// On", methName, " sets the hook serving ", methName, ".")
+		g.P("func (m *", mock, ") On", methName, "(fn ", hookType, ") {")
+		g.P("m.SetHook(\"", methName, "\", fn)")
+		g.P("}")
+		g.P()
+		g.P("func (m *", mock, ") ", g.serverMethodSignature(servName, method), " {")
+		g.P("err := ", unimplemented(mock, methName))
+		g.P("if fn, ok := m.Hook(\"", methName, "\").(", hookType, "); ok {")
+		g.P("err = ", call)
+		g.P("}")
+		g.P("m.Record(\"", methName, "\", ", in, ", ", out, ", err)")
+		g.P("return err")
+		g.P("}")
+		g.P()
+	}
+
+	// Client mock.
+	mock = "Mock" + servName + "Client"
+	g.P("// ", mock, " is a mock implementation of ", servName, "Client.")
+	g.P("// Methods without a hook fail with Unimplemented, and AsyncXxx methods")
+	g.P("// share the hooks of their sync methods.")
+	g.P("type ", mock, " struct {")
+	g.P(g.frog("MockRecorder"))
+	g.P("}")
+	g.P()
+	g.P("var _ ", servName, "Client = (*", mock, ")(nil)")
+	g.P()
+	for _, method := range service.Methods {
+		methName := method.GoName
+		inType := g.typeName(method.Input)
+		outType := g.typeName(method.Output)
+		streamType := servName + "_" + methName + "Client"
+
+		if isStreaming(method) {
+			hookType := "func(" + g.context() + ", *" + inType + ") (" + streamType + ", error)"
+			call, in := "fn(ctx, in)", "in"
+			if method.Desc.IsStreamingClient() {
+				hookType = "func(" + g.context() + ") (" + streamType + ", error)"
+				call, in = "fn(ctx)", "nil"
+			}
+			g.P("// On", methName, " sets the hook serving ", methName, ".")
+			g.P("func (m *", mock, ") On", methName, "(fn ", hookType, ") {")
+			g.P("m.SetHook(\"", methName, "\", fn)")
+			g.P("}")
+			g.P()
+			g.P("func (m *", mock, ") ", g.stubStreamSignature(servName, method), " {")
+			g.P("if fn, ok := m.Hook(\"", methName, "\").(", hookType, "); ok {")
+			g.P("stream, err := ", call)
+			g.P("m.Record(\"", methName, "\", ", in, ", nil, err)")
+			g.P("return stream, err")
+			g.P("}")
+			g.P("err := ", unimplemented(mock, methName))
+			g.P("m.Record(\"", methName, "\", ", in, ", nil, err)")
+			g.P("return nil, err")
+			g.P("}")
+			g.P()
+			continue
+		}
+
+		hookType := "func(" + g.context() + ", *" + inType + ", *" + outType + ") error"
+		g.P("// On", methName, " sets the hook serving ", methName, " and Async", methName, ".")
+		g.P("func (m *", mock, ") On", methName, "(fn ", hookType, ") {")
+		g.P("m.SetHook(\"", methName, "\", fn)")
+		g.P("}")
+		g.P()
+		g.P("func (m *", mock, ") ", g.stubSignature(method), " {")
+		g.P("err := ", unimplemented(mock, methName))
+		g.P("if fn, ok := m.Hook(\"", methName, "\").(", hookType, "); ok {")
+		g.P("err = fn(ctx, in, out)")
+		g.P("}")
+		g.P("m.Record(\"", methName, "\", in, out, err)")
+		g.P("return err")
+		g.P("}")
+		g.P()
+		g.P("func (m *", mock, ") ", g.stubAsyncSignature(method), " {")
+		g.P("call := ", g.frog("NewDefaultCall"), "(in, out)")
+		g.P("call.Close(m.", methName, "(ctx, in, out))")
+		g.P("return ", g.frog("NewTypedCall"), "(call, out)")
+		g.P("}")
+		g.P()
+	}
+}
+
+// stubSignature returns the stub signature for a method.
+func (g *generator) stubSignature(method *protogen.Method) string {
+	return fmt.Sprintf("%s(ctx %s, in *%s, out *%s) error", method.GoName, g.context(), g.typeName(method.Input), g.typeName(method.Output))
+}
+
+func (g *generator) stubAsyncSignature(method *protogen.Method) string {
+	outType := g.typeName(method.Output)
+	return fmt.Sprintf("Async%s(ctx %s, in *%s, out *%s) *%s[*%s]", method.GoName, g.context(), g.typeName(method.Input), outType, g.frog("TypedCall"), outType)
+}
+
+// stubStreamSignature returns the stub signature for a streaming method.
+func (g *generator) stubStreamSignature(servName string, method *protogen.Method) string {
+	if method.Desc.IsStreamingClient() {
+		return fmt.Sprintf("%s(ctx %s) (%s_%sClient, error)", method.GoName, g.context(), servName, method.GoName)
+	}
+	return fmt.Sprintf("%s(ctx %s, in *%s) (%s_%sClient, error)", method.GoName, g.context(), g.typeName(method.Input), servName, method.GoName)
+}
+
+// serverMethodSignature returns the server-side signature for a method, with named parameters.
+func (g *generator) serverMethodSignature(servName string, method *protogen.Method) string {
+	ctx := g.context()
+	streamType := servName + "_" + method.GoName + "Server"
+	if method.Desc.IsStreamingClient() {
+		return method.GoName + "(ctx " + ctx + ", stream " + streamType + ") error"
+	}
+	if method.Desc.IsStreamingServer() {
+		return method.GoName + "(ctx " + ctx + ", in *" + g.typeName(method.Input) + ", stream " + streamType + ") error"
+	}
+	return method.GoName + "(ctx " + ctx + ", in *" + g.typeName(method.Input) + ", out *" + g.typeName(method.Output) + ") error"
+}
+
+// asyncServerMethodSignature returns the signature of a method served
+// asynchronously, with named parameters.
+func (g *generator) asyncServerMethodSignature(servName string, method *protogen.Method) string {
+	if isStreaming(method) {
+		return g.serverMethodSignature(servName, method)
+	}
+	return method.GoName + "(ctrl " + g.frog("RpcController") + ", in *" + g.typeName(method.Input) + ", out *" + g.typeName(method.Output) + ")"
+}
+
+// asyncServerSignature returns the signature of a method served asynchronously.
+func (g *generator) asyncServerSignature(servName string, method *protogen.Method) string {
+	if isStreaming(method) {
+		return g.serverSignature(servName, method)
+	}
+	return method.GoName + "(" + g.frog("RpcController") + ", *" + g.typeName(method.Input) + ", *" + g.typeName(method.Output) + ")"
+}
+
+// serverSignature returns the server-side signature for a method.
+func (g *generator) serverSignature(servName string, method *protogen.Method) string {
+	ctx := g.context()
+	streamType := servName + "_" + method.GoName + "Server"
+	if method.Desc.IsStreamingClient() {
+		return method.GoName + "(" + ctx + ", " + streamType + ") error"
+	}
+	if method.Desc.IsStreamingServer() {
+		return method.GoName + "(" + ctx + ", *" + g.typeName(method.Input) + ", " + streamType + ") error"
+	}
+	return method.GoName + "(" + ctx + ", *" + g.typeName(method.Input) + ", *" + g.typeName(method.Output) + ") error"
+}
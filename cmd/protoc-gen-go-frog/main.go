@@ -0,0 +1,38 @@
+// protoc-gen-go-frog is a plugin for the Google protocol buffer compiler to
+// generate frog rpc code. Install it by building this program and making it
+// accessible within your PATH with the name:
+//
+//	protoc-gen-go-frog
+//
+// The 'go-frog' suffix becomes part of the argument for the protocol compiler,
+// such that it can be invoked as:
+//
+//	protoc --go_out=. --go-frog_out=. path/to/file.proto
+//
+// This generates a file_frog.pb.go next to the file.pb.go of protoc-gen-go.
+// Pass --go-frog_opt=frog_mock=true to also generate mocks.
+package main
+
+import (
+	"flag"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func main() {
+	var flags flag.FlagSet
+	mock := flags.Bool("frog_mock", false, "generate mocks of the service and client interfaces")
+
+	protogen.Options{
+		ParamFunc: flags.Set,
+	}.Run(func(gen *protogen.Plugin) error {
+		gen.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+		for _, f := range gen.Files {
+			if f.Generate {
+				generateFile(gen, f, *mock)
+			}
+		}
+		return nil
+	})
+}
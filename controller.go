@@ -0,0 +1,56 @@
+package frog
+
+import "context"
+
+// RpcController is the server side of a call served asynchronously, modeled
+// on the RpcController of C++ protobuf services. Methods taking it instead
+// of a context.Context may return at once and complete the call later, e.g.
+// after a queue or another rpc:
+//
+//	func (s *EchoServiceImpl) Echo(ctrl frog.RpcController, in *ProtoEchoRequest, out *ProtoEchoResponse) {
+//		go func() {
+//			out.Text = in.Text
+//			ctrl.Done(nil)
+//		}()
+//	}
+type RpcController interface {
+	// Context returns the context of the call.
+	Context() context.Context
+
+	// Done completes the call with err, once the response is set.
+	// Calls after the first one, or after the call is canceled, are ignored.
+	Done(err error)
+
+	// IsCanceled reports whether the call was canceled, by the client or by
+	// its deadline, before Done. The response is not used then.
+	IsCanceled() bool
+
+	// NotifyOnCancel registers fn to be called once the call is canceled.
+	// fn is called at once if it is already canceled, and never if Done is
+	// called first. fn should not block.
+	NotifyOnCancel(fn func())
+}
+
+// serverController implements RpcController on the call returned by GoMethod
+type serverController struct {
+	ctx  context.Context
+	call *DefaultCall
+}
+
+func (c *serverController) Context() context.Context {
+	return c.ctx
+}
+
+func (c *serverController) Done(err error) {
+	c.call.Close(err)
+}
+
+func (c *serverController) IsCanceled() bool {
+	c.call.mu.Lock()
+	defer c.call.mu.Unlock()
+	return c.call.canceled
+}
+
+func (c *serverController) NotifyOnCancel(fn func()) {
+	c.call.OnCancel(func(error) { fn() })
+}
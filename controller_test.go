@@ -0,0 +1,148 @@
+package frog
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// asyncEcho registers testService served asynchronously, with the Echo
+// method served by echo, and returns Echo.
+func asyncEcho(t *testing.T, echo func(ctrl RpcController, in, out *wrapperspb.StringValue)) *RpcMethod {
+	t.Helper()
+	handlers := &ServiceHandlers{
+		AsyncDispatch: func(service interface{}, index int, ctrl RpcController, request proto.Message, response proto.Message) {
+			if index != testEcho {
+				ctrl.Done(Errorf(Unimplemented, "unary method %d", index))
+				return
+			}
+			echo(ctrl, request.(*wrapperspb.StringValue), response.(*wrapperspb.StringValue))
+		},
+		NewRequest:  func(int) proto.Message { return new(wrapperspb.StringValue) },
+		NewResponse: func(int) proto.Message { return new(wrapperspb.StringValue) },
+		Streams:     []StreamHandler{testUpload: unimplementedStream, testChat: unimplementedStream},
+	}
+	var methods []*RpcMethod
+	if err := RegisterServiceHandlers(testService, nil, func(m []*RpcMethod) error { methods = m; return nil }, handlers); err != nil {
+		t.Fatal(err)
+	}
+	return methods[testEcho]
+}
+
+func TestAsyncDispatch(t *testing.T) {
+	release := make(chan struct{})
+	echo := asyncEcho(t, func(ctrl RpcController, in, out *wrapperspb.StringValue) {
+		// requests of "block" only complete by cancellation
+		wait := release
+		if in.Value == "block" {
+			wait = nil
+		}
+		go func() {
+			select {
+			case <-wait:
+				out.Value = in.Value
+				ctrl.Done(nil)
+			case <-ctrl.Context().Done():
+			}
+		}()
+	})
+
+	// the handler returns before the call completes
+	out := new(wrapperspb.StringValue)
+	call := GoMethod(echo, context.Background(), wrapperspb.String("hi"), out)
+	select {
+	case <-call.Done():
+		t.Fatal("call completed before the handler called Done")
+	default:
+	}
+	close(release)
+	<-call.Done()
+	if call.Error() != nil || out.Value != "hi" {
+		t.Errorf("call = %q, %v, want hi", out.Value, call.Error())
+	}
+
+	// CallMethod waits for Done, and cancellation completes the call
+	if err := CallMethod(echo, context.Background(), wrapperspb.String("hi"), out); err != nil {
+		t.Errorf("CallMethod = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := CallMethod(echo, ctx, wrapperspb.String("block"), out); ErrorCode(err) != Canceled {
+		t.Errorf("CallMethod of canceled ctx = %v, want Canceled", err)
+	}
+}
+
+func TestControllerCanceled(t *testing.T) {
+	ctrls := make(chan RpcController, 1)
+	echo := asyncEcho(t, func(ctrl RpcController, in, out *wrapperspb.StringValue) { ctrls <- ctrl })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	call := GoMethod(echo, ctx, wrapperspb.String("hi"), new(wrapperspb.StringValue))
+	ctrl := <-ctrls
+	notified := make(chan struct{}, 2)
+	ctrl.NotifyOnCancel(func() { notified <- struct{}{} })
+	if ctrl.IsCanceled() {
+		t.Error("call canceled before its ctx")
+	}
+
+	cancel()
+	<-call.Done()
+	if ErrorCode(call.Error()) != Canceled || !ctrl.IsCanceled() {
+		t.Errorf("call of canceled ctx = %v, canceled %v, want Canceled", call.Error(), ctrl.IsCanceled())
+	}
+	inTime(t, "cancel notification", func() { <-notified })
+
+	// notified at once after cancel
+	ctrl.NotifyOnCancel(func() { notified <- struct{}{} })
+	select {
+	case <-notified:
+	default:
+		t.Error("NotifyOnCancel after cancel not called")
+	}
+
+	// Done after cancel is ignored
+	ctrl.Done(nil)
+	if ErrorCode(call.Error()) != Canceled {
+		t.Errorf("call = %v after Done, want Canceled", call.Error())
+	}
+}
+
+func TestControllerDone(t *testing.T) {
+	ctrls := make(chan RpcController, 1)
+	echo := asyncEcho(t, func(ctrl RpcController, in, out *wrapperspb.StringValue) { ctrls <- ctrl })
+
+	for _, tc := range []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"ok", nil, OK},
+		{"error", Errorf(NotFound, "no user"), NotFound},
+	} {
+		out := new(wrapperspb.StringValue)
+		call := GoMethod(echo, context.Background(), wrapperspb.String("hi"), out)
+		ctrl := <-ctrls
+		ctrl.NotifyOnCancel(func() { t.Errorf("%s: call completed by Done notified of cancel", tc.name) })
+
+		// the handler returned, Done completes the call later
+		select {
+		case <-call.Done():
+			t.Fatalf("%s: call completed before Done", tc.name)
+		default:
+		}
+		out.Value = "hi"
+		ctrl.Done(tc.err)
+		<-call.Done()
+		if ErrorCode(call.Error()) != tc.want || ctrl.IsCanceled() {
+			t.Errorf("%s: call = %v, canceled %v, want %v", tc.name, call.Error(), ctrl.IsCanceled(), tc.want)
+		}
+
+		// a second Done is ignored
+		ctrl.Done(Errorf(Internal, "second Done"))
+		if ErrorCode(call.Error()) != tc.want {
+			t.Errorf("%s: call = %v after a second Done, want %v", tc.name, call.Error(), tc.want)
+		}
+	}
+}
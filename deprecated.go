@@ -0,0 +1,27 @@
+package frog
+
+import "sync/atomic"
+
+// deprecationHandler holds the func(*MethodDesc) set by SetDeprecationHandler
+var deprecationHandler atomic.Value
+
+// SetDeprecationHandler sets the function called whenever a generated stub
+// calls a deprecated method, e.g. to log a warning. A nil handler, the
+// default, ignores such calls.
+//
+// Example:
+//
+//	frog.SetDeprecationHandler(func(md *frog.MethodDesc) {
+//		log.Printf("calling deprecated method %s", md.FullName())
+//	})
+func SetDeprecationHandler(handler func(md *MethodDesc)) {
+	deprecationHandler.Store(handler)
+}
+
+// NotifyDeprecated calls the deprecation handler with md, if any.
+// It is called from generated code
+func NotifyDeprecated(md *MethodDesc) {
+	if handler, _ := deprecationHandler.Load().(func(*MethodDesc)); handler != nil {
+		handler(md)
+	}
+}
@@ -5,15 +5,64 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
 
-	proto "github.com/golang/protobuf/proto"
 	desc "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 // ServiceDesc wraps ServiceDescriptorProto and provide more functions
 type ServiceDesc struct {
 	*desc.ServiceDescriptorProto
-	methods []*MethodDesc
+	methods    []*MethodDesc
+	file       *desc.FileDescriptorProto
+	descriptor protoreflect.ServiceDescriptor
+	options    sync.Map // optionKey to optionValue
+}
+
+// Descriptor returns the protoreflect descriptor of service
+func (sd *ServiceDesc) Descriptor() protoreflect.ServiceDescriptor {
+	return sd.descriptor
+}
+
+// FullName returns the fully-qualified service name, e.g. "pkg.EchoService"
+func (sd *ServiceDesc) FullName() string {
+	if pkg := sd.file.GetPackage(); pkg != "" {
+		return pkg + "." + sd.GetName()
+	}
+	return sd.GetName()
+}
+
+// File returns the descriptor of the proto file which defines the service
+func (sd *ServiceDesc) File() *desc.FileDescriptorProto {
+	return sd.file
+}
+
+// Imports returns the descriptors of the files imported by the file which
+// defines the service, in import order. Unknown files are skipped.
+func (sd *ServiceDesc) Imports() []*desc.FileDescriptorProto {
+	imports := make([]*desc.FileDescriptorProto, 0, len(sd.file.GetDependency()))
+	for _, dep := range sd.file.GetDependency() {
+		if fd := FileDescriptor(dep); fd != nil {
+			imports = append(imports, fd)
+		}
+	}
+	return imports
+}
+
+// MethodByName returns the method of service with the given proto name, nil if not found
+func (sd *ServiceDesc) MethodByName(name string) *MethodDesc {
+	for _, md := range sd.methods {
+		if md.GetName() == name {
+			return md
+		}
+	}
+	return nil
 }
 
 // NumMethod returns the number of methods of service
@@ -32,7 +81,57 @@ func (sd *ServiceDesc) Method(index int) *MethodDesc {
 // MethodDesc wraps MethodDescriptorProto and provide more functions
 type MethodDesc struct {
 	*desc.MethodDescriptorProto
-	service *ServiceDesc
+	service    *ServiceDesc
+	index      int
+	descriptor protoreflect.MethodDescriptor
+
+	policyOnce sync.Once
+	policy     *CallPolicy
+}
+
+// Descriptor returns the protoreflect descriptor of method
+func (md *MethodDesc) Descriptor() protoreflect.MethodDescriptor {
+	return md.descriptor
+}
+
+// RequestType returns the type of the method's request message, resolved
+// through protoregistry. It returns nil if the message is not linked in.
+func (md *MethodDesc) RequestType() protoreflect.MessageType {
+	return messageType(md.descriptor.Input())
+}
+
+// ResponseType returns the type of the method's response message, resolved
+// through protoregistry. It returns nil if the message is not linked in.
+func (md *MethodDesc) ResponseType() protoreflect.MessageType {
+	return messageType(md.descriptor.Output())
+}
+
+func messageType(message protoreflect.MessageDescriptor) protoreflect.MessageType {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(message.FullName())
+	if err != nil {
+		return nil
+	}
+	return mt
+}
+
+// FullName returns the full method name, e.g. "/pkg.EchoService/Echo"
+func (md *MethodDesc) FullName() string {
+	return "/" + md.service.FullName() + "/" + md.GetName()
+}
+
+// Index returns the method's index in its service
+func (md *MethodDesc) Index() int {
+	return md.index
+}
+
+// IsStreaming reports whether the client or the server streams messages
+func (md *MethodDesc) IsStreaming() bool {
+	return md.GetClientStreaming() || md.GetServerStreaming()
+}
+
+// IsDeprecated reports whether the method or its service is marked deprecated
+func (md *MethodDesc) IsDeprecated() bool {
+	return md.GetOptions().GetDeprecated() || md.service.GetOptions().GetDeprecated()
 }
 
 // GetServiceDesc returns method's service descriptor
@@ -41,40 +140,162 @@ func (md *MethodDesc) GetServiceDesc() *ServiceDesc {
 }
 
 var (
-	serviceDescriptors = make(map[string]*ServiceDesc)
+	registryMu         sync.RWMutex                                 // protect following maps
+	serviceDescriptors = make(map[string]*ServiceDesc)              // keyed by fully-qualified service name
+	fileDescriptors    = make(map[string]*desc.FileDescriptorProto) // keyed by proto file name
 )
 
-// GenerateServiceDesc called by generated code to generate ServiceDesc and MethodDesc
-func GenerateServiceDesc(fileDescriptor []byte) {
+// decodeFileDescriptor decodes a gzipped FileDescriptorProto
+func decodeFileDescriptor(fileDescriptor []byte) (*desc.FileDescriptorProto, error) {
 	r, err := gzip.NewReader(bytes.NewReader(fileDescriptor))
 	if err != nil {
-		panic(fmt.Sprintf("failed to open gzip reader: %v", err))
+		return nil, fmt.Errorf("failed to open gzip reader: %v", err)
 	}
 	defer r.Close()
 
 	b, err := ioutil.ReadAll(r)
 	if err != nil {
-		panic(fmt.Errorf("failed to uncompress descriptor: %v", err))
+		return nil, fmt.Errorf("failed to uncompress descriptor: %v", err)
 	}
 
 	fd := new(desc.FileDescriptorProto)
 	if err := proto.Unmarshal(b, fd); err != nil {
-		panic(fmt.Sprintf("unmarshal FileDescriptorProto failed, err=%s", err))
+		return nil, fmt.Errorf("unmarshal FileDescriptorProto failed, err=%s", err)
 	}
+	return fd, nil
+}
 
-	for _, service := range fd.Service {
-		servDesc := &ServiceDesc{service, make([]*MethodDesc, 0, len(service.Method))}
-		servDescName := GenerateServiceDescName(service.GetName())
-		serviceDescriptors[servDescName] = servDesc
+// GenerateServiceDesc called by generated code to generate ServiceDesc and MethodDesc
+func GenerateServiceDesc(fileDescriptor []byte) {
+	fd, err := decodeFileDescriptor(fileDescriptor)
+	if err != nil {
+		panic(err)
+	}
+	// the proto package has built the file, unless it was not registered to it
+	file, err := protoregistry.GlobalFiles.FindFileByPath(fd.GetName())
+	if err != nil {
+		file, err = protodesc.NewFile(fd, protoregistry.GlobalFiles)
+		if err != nil {
+			panic(fmt.Errorf("build descriptor of %s failed: %v", fd.GetName(), err))
+		}
+	}
+	registerFile(file, fd)
+}
+
+// GenerateServiceDescFromFile is like GenerateServiceDesc, and takes the
+// file descriptor of protoc-gen-go-frog generated code
+func GenerateServiceDescFromFile(file protoreflect.FileDescriptor) {
+	registerFile(file, protodesc.ToFileDescriptorProto(file))
+}
 
-		for _, method := range service.Method {
-			methDesc := &MethodDesc{method, servDesc}
+// registerFile generates ServiceDesc and MethodDesc for the services of file.
+// It panics if a service is already defined by another file.
+func registerFile(file protoreflect.FileDescriptor, fd *desc.FileDescriptorProto) {
+	services := make([]*ServiceDesc, 0, len(fd.Service))
+	for i, service := range fd.Service {
+		sd := file.Services().Get(i)
+		servDesc := &ServiceDesc{
+			ServiceDescriptorProto: service,
+			methods:                make([]*MethodDesc, 0, len(service.Method)),
+			file:                   fd,
+			descriptor:             sd,
+		}
+		for j, method := range service.Method {
+			methDesc := &MethodDesc{
+				MethodDescriptorProto: method,
+				service:               servDesc,
+				index:                 j,
+				descriptor:            sd.Methods().Get(j),
+			}
 			servDesc.methods = append(servDesc.methods, methDesc)
 		}
+		services = append(services, servDesc)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := fileDescriptors[fd.GetName()]; ok {
+		// registered already
+		return
+	}
+	for _, servDesc := range services {
+		if old, ok := serviceDescriptors[servDesc.FullName()]; ok {
+			panic(fmt.Errorf("frog: service %s is defined in both %s and %s",
+				servDesc.FullName(), old.file.GetName(), fd.GetName()))
+		}
+	}
+	fileDescriptors[fd.GetName()] = fd
+	for _, servDesc := range services {
+		serviceDescriptors[servDesc.FullName()] = servDesc
 	}
 }
 
-// ServiceDescriptorProto called by generated code
+// ServiceDescriptor returns the service descriptor of the fully-qualified
+// service name, e.g. "pkg.EchoService", nil if it is not registered.
+// It is called from generated code.
+//
+// The descriptor name generated by GenerateServiceDescName, used by code
+// generated before, is still accepted if only one service has that name.
 func ServiceDescriptor(servicename string) *ServiceDesc {
-	return serviceDescriptors[servicename]
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	if sd, ok := serviceDescriptors[servicename]; ok {
+		return sd
+	}
+	var found *ServiceDesc
+	for _, sd := range serviceDescriptors {
+		if GenerateServiceDescName(sd.GetName()) == servicename {
+			if found != nil {
+				return nil
+			}
+			found = sd
+		}
+	}
+	return found
+}
+
+// LookupMethod returns the method descriptor of the full method name,
+// e.g. "/pkg.EchoService/Echo", nil if it is not registered.
+func LookupMethod(fullMethod string) *MethodDesc {
+	name := strings.TrimPrefix(fullMethod, "/")
+	i := strings.LastIndex(name, "/")
+	if i < 0 {
+		return nil
+	}
+	registryMu.RLock()
+	sd, ok := serviceDescriptors[name[:i]]
+	registryMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return sd.MethodByName(name[i+1:])
+}
+
+// Services returns all registered service descriptors, sorted by full name
+func Services() []*ServiceDesc {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	services := make([]*ServiceDesc, 0, len(serviceDescriptors))
+	for _, sd := range serviceDescriptors {
+		services = append(services, sd)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].FullName() < services[j].FullName() })
+	return services
+}
+
+// FileDescriptor returns the descriptor of the named proto file. Files
+// defining services are looked up first, then every file registered to
+// protoregistry. It returns nil if the file is unknown.
+func FileDescriptor(filename string) *desc.FileDescriptorProto {
+	registryMu.RLock()
+	fd, ok := fileDescriptors[filename]
+	registryMu.RUnlock()
+	if ok {
+		return fd
+	}
+	file, err := protoregistry.GlobalFiles.FindFileByPath(filename)
+	if err != nil {
+		return nil
+	}
+	return protodesc.ToFileDescriptorProto(file)
 }
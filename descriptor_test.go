@@ -0,0 +1,156 @@
+package frog
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// registerServiceFile registers a file of package pkg defining service with
+// methods taking and returning a google.protobuf.StringValue.
+func registerServiceFile(t *testing.T, pkg, filename, service string, methods ...string) *ServiceDesc {
+	t.Helper()
+	sd := &descriptorpb.ServiceDescriptorProto{Name: proto.String(service)}
+	for _, name := range methods {
+		sd.Method = append(sd.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".google.protobuf.StringValue"),
+			OutputType: proto.String(".google.protobuf.StringValue"),
+		})
+	}
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String(filename),
+		Package:    proto.String(pkg),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/wrappers.proto"},
+		Service:    []*descriptorpb.ServiceDescriptorProto{sd},
+	}
+	file, err := protodesc.NewFile(fd, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	GenerateServiceDescFromFile(file)
+	return ServiceDescriptor(pkg + "." + service)
+}
+
+func TestRegisterDuplicateService(t *testing.T) {
+	// the same file is registered once
+	registerTestService("frog.test", "frog/test.proto")
+	if sd := ServiceDescriptor("frog.test.Test"); sd != testService {
+		t.Errorf("service of a file registered again = %p, want %p", sd, testService)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("service defined by two files registered")
+		}
+		if sd := ServiceDescriptor("frog.test.Test"); sd != testService {
+			t.Errorf("service after duplicate registration = %p, want %p", sd, testService)
+		}
+		if fd := FileDescriptor("frog/test_duplicate.proto"); fd != nil {
+			t.Error("file defining a duplicate service registered")
+		}
+	}()
+	registerServiceFile(t, "frog.test", "frog/test_duplicate.proto", "Test", "Get")
+}
+
+func TestServiceDescriptor(t *testing.T) {
+	other := registerTestService("frog.test.other", "frog/test_other.proto")
+	if other == testService {
+		t.Fatal("services of different packages share a descriptor")
+	}
+	if sd := ServiceDescriptor("frog.test.other.Test"); sd != other {
+		t.Errorf("service frog.test.other.Test = %v, want %v", sd, other)
+	}
+	if sd := ServiceDescriptor("frog.test.Unknown"); sd != nil {
+		t.Errorf("unknown service = %v, want nil", sd)
+	}
+
+	// legacy names are accepted only if unique
+	if sd := ServiceDescriptor("Test_ServiceDesc"); sd != nil {
+		t.Errorf("legacy name of two services = %v, want nil", sd.FullName())
+	}
+	registerServiceFile(t, "frog.test", "frog/test_solo.proto", "Solo", "Get")
+	if sd := ServiceDescriptor("Solo_ServiceDesc"); sd == nil || sd.FullName() != "frog.test.Solo" {
+		t.Errorf("legacy name of a unique service = %v, want frog.test.Solo", sd)
+	}
+}
+
+func TestServices(t *testing.T) {
+	services := Services()
+	found := false
+	for i, sd := range services {
+		if i > 0 && services[i-1].FullName() >= sd.FullName() {
+			t.Errorf("services not sorted: %s before %s", services[i-1].FullName(), sd.FullName())
+		}
+		found = found || sd == testService
+	}
+	if !found {
+		t.Error("test service not listed")
+	}
+}
+
+func TestLookupMethod(t *testing.T) {
+	other := registerTestService("frog.test.other", "frog/test_other.proto")
+	for _, tc := range []struct {
+		name string
+		want *MethodDesc
+	}{
+		{"/frog.test.Test/Echo", testService.Method(testEcho)},
+		{"frog.test.Test/Chat", testService.Method(testChat)},
+		{"/frog.test.other.Test/Echo", other.Method(testEcho)},
+		{"/frog.test.Test/Unknown", nil},
+		{"/frog.test.Unknown/Echo", nil},
+		{"/frog.test.Test/echo", nil},
+		{"Echo", nil},
+		{"", nil},
+	} {
+		if got := LookupMethod(tc.name); got != tc.want {
+			t.Errorf("LookupMethod(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if name := testService.Method(testEcho).FullName(); LookupMethod(name) != testService.Method(testEcho) {
+		t.Errorf("LookupMethod(%q) does not return the method", name)
+	}
+}
+
+func TestMethodByName(t *testing.T) {
+	for i := 0; i < testService.NumMethod(); i++ {
+		md := testService.Method(i)
+		if got := testService.MethodByName(md.GetName()); got != md {
+			t.Errorf("MethodByName(%q) = %v, want method %d", md.GetName(), got, i)
+		}
+		if md.Index() != i || md.GetServiceDesc() != testService {
+			t.Errorf("method %d has index %d of service %v", i, md.Index(), md.GetServiceDesc().FullName())
+		}
+	}
+	if md := testService.MethodByName("echo"); md != nil {
+		t.Errorf("MethodByName(echo) = %v, want nil", md)
+	}
+}
+
+func TestFileDescriptor(t *testing.T) {
+	if fd := FileDescriptor("frog/test.proto"); fd != testService.File() {
+		t.Errorf("file of the test service = %v, want %v", fd.GetName(), testService.File().GetName())
+	}
+	if fd := FileDescriptor("google/protobuf/wrappers.proto"); fd.GetPackage() != "google.protobuf" {
+		t.Errorf("registered file without services = %v, want google/protobuf/wrappers.proto", fd)
+	}
+	if fd := FileDescriptor("frog/unknown.proto"); fd != nil {
+		t.Errorf("unknown file = %v, want nil", fd.GetName())
+	}
+
+	imports := testService.Imports()
+	want := []string{"google/protobuf/descriptor.proto", "google/protobuf/wrappers.proto"}
+	if len(imports) != len(want) {
+		t.Fatalf("%d imports, want %d", len(imports), len(want))
+	}
+	for i, fd := range imports {
+		if fd.GetName() != want[i] {
+			t.Errorf("import %d = %s, want %s", i, fd.GetName(), want[i])
+		}
+	}
+}
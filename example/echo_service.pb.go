@@ -11,6 +11,7 @@ import google_protobuf "github.com/golang/protobuf/protoc-gen-go/descriptor"
 import (
 	"context"
 	frog "github.com/yplusplus/frog"
+	protoV2 "google.golang.org/protobuf/proto"
 )
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -46,49 +47,168 @@ var _ context.Context
 
 // Stub for EchoService service
 
+// Full names of the methods of EchoService service, as returned by MethodDesc.FullName.
+const (
+	EchoService_Echo_FullMethodName  = "/EchoService/Echo"
+	EchoService_Echo2_FullMethodName = "/EchoService/Echo2"
+)
+
+// EchoService_Echo_MethodDesc returns the descriptor of the Echo method of EchoService service.
+func EchoService_Echo_MethodDesc() *frog.MethodDesc {
+	return EchoService_ServiceDesc.Method(0)
+}
+
+// EchoService_Echo2_MethodDesc returns the descriptor of the Echo2 method of EchoService service.
+func EchoService_Echo2_MethodDesc() *frog.MethodDesc {
+	return EchoService_ServiceDesc.Method(1)
+}
+
+// EchoServiceClient is the client API for EchoService service.
+type EchoServiceClient interface {
+	Echo(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) error
+	// AsyncEcho is the asynchronous version of Echo.
+	AsyncEcho(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) *frog.TypedCall[*ProtoEchoResponse]
+	Echo2(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) error
+	// AsyncEcho2 is the asynchronous version of Echo2.
+	AsyncEcho2(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) *frog.TypedCall[*ProtoEchoResponse]
+}
+
 type echoServiceStub struct {
 	channel frog.RpcChannel
 }
 
-func NewEchoServiceStub(channel frog.RpcChannel) *echoServiceStub {
+// NewEchoServiceStub returns the EchoServiceClient calling methods on channel.
+func NewEchoServiceStub(channel frog.RpcChannel) EchoServiceClient {
 	return &echoServiceStub{channel}
 }
 
 func (stub *echoServiceStub) Echo(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) error {
-	err := stub.Call(EchoService_ServiceDesc.Method(0), ctx, in, out)
+	err := stub.Call(EchoService_Echo_MethodDesc(), ctx, in, out)
 	return err
 }
 
-func (stub *echoServiceStub) AsyncEcho(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) frog.RpcCall {
-	return stub.Go(EchoService_ServiceDesc.Method(0), ctx, in, out)
+// AsyncEcho is the asynchronous version of Echo.
+func (stub *echoServiceStub) AsyncEcho(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) *frog.TypedCall[*ProtoEchoResponse] {
+	return frog.NewTypedCall(stub.Go(EchoService_Echo_MethodDesc(), ctx, in, out), out)
 }
 
 func (stub *echoServiceStub) Echo2(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) error {
-	err := stub.Call(EchoService_ServiceDesc.Method(1), ctx, in, out)
+	err := stub.Call(EchoService_Echo2_MethodDesc(), ctx, in, out)
 	return err
 }
 
-func (stub *echoServiceStub) AsyncEcho2(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) frog.RpcCall {
-	return stub.Go(EchoService_ServiceDesc.Method(1), ctx, in, out)
+// AsyncEcho2 is the asynchronous version of Echo2.
+func (stub *echoServiceStub) AsyncEcho2(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) *frog.TypedCall[*ProtoEchoResponse] {
+	return frog.NewTypedCall(stub.Go(EchoService_Echo2_MethodDesc(), ctx, in, out), out)
 }
 
 func (stub *echoServiceStub) Call(method *frog.MethodDesc, ctx context.Context, in proto.Message, out proto.Message) error {
-	call := stub.channel.Go(method, ctx, in, out)
+	call := stub.channel.Go(method, ctx, proto.MessageV2(in), proto.MessageV2(out))
 	<-call.Done()
 	return call.Error()
 }
 
 func (stub *echoServiceStub) Go(method *frog.MethodDesc, ctx context.Context, in proto.Message, out proto.Message) frog.RpcCall {
-	return stub.channel.Go(method, ctx, in, out)
+	return stub.channel.Go(method, ctx, proto.MessageV2(in), proto.MessageV2(out))
 }
 
+// EchoService is the server API for EchoService service.
 type EchoService interface {
 	Echo(context.Context, *ProtoEchoRequest, *ProtoEchoResponse) error
 	Echo2(context.Context, *ProtoEchoRequest, *ProtoEchoResponse) error
 }
 
+// UnimplementedEchoService should be embedded by implementations of EchoService,
+// so they keep compiling and registering when methods are added to the service.
+type UnimplementedEchoService struct{}
+
+func (UnimplementedEchoService) Echo(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) error {
+	return frog.Errorf(frog.Unimplemented, "method Echo not implemented")
+}
+
+func (UnimplementedEchoService) Echo2(ctx context.Context, in *ProtoEchoRequest, out *ProtoEchoResponse) error {
+	return frog.Errorf(frog.Unimplemented, "method Echo2 not implemented")
+}
+
 func RegisterEchoService(service EchoService, register frog.MethodsRegister) error {
-	return frog.RegisterService(EchoService_ServiceDesc, service, register)
+	return frog.RegisterServiceHandlers(EchoService_ServiceDesc, service, register, _EchoService_Handlers)
+}
+
+var _EchoService_Handlers = &frog.ServiceHandlers{
+	Dispatch:    _EchoService_Dispatch,
+	NewRequest:  _EchoService_NewRequest,
+	NewResponse: _EchoService_NewResponse,
+}
+
+func _EchoService_Dispatch(service interface{}, index int, ctx context.Context, in protoV2.Message, out protoV2.Message) error {
+	switch index {
+	case 0:
+		return service.(EchoService).Echo(ctx, proto.MessageV1(in).(*ProtoEchoRequest), proto.MessageV1(out).(*ProtoEchoResponse))
+	case 1:
+		return service.(EchoService).Echo2(ctx, proto.MessageV1(in).(*ProtoEchoRequest), proto.MessageV1(out).(*ProtoEchoResponse))
+	}
+	return frog.Errorf(frog.Unimplemented, "EchoService has no unary method %d", index)
+}
+
+func _EchoService_NewRequest(index int) protoV2.Message {
+	switch index {
+	case 0:
+		return proto.MessageV2(new(ProtoEchoRequest))
+	case 1:
+		return proto.MessageV2(new(ProtoEchoRequest))
+	}
+	return nil
+}
+
+func _EchoService_NewResponse(index int) protoV2.Message {
+	switch index {
+	case 0:
+		return proto.MessageV2(new(ProtoEchoResponse))
+	case 1:
+		return proto.MessageV2(new(ProtoEchoResponse))
+	}
+	return nil
+}
+
+// EchoServiceAsync is the server API for EchoService service served asynchronously:
+// unary methods may return at once and complete the call later with ctrl.Done.
+type EchoServiceAsync interface {
+	Echo(frog.RpcController, *ProtoEchoRequest, *ProtoEchoResponse)
+	Echo2(frog.RpcController, *ProtoEchoRequest, *ProtoEchoResponse)
+}
+
+// UnimplementedEchoServiceAsync should be embedded by implementations of EchoServiceAsync,
+// so they keep compiling and registering when methods are added to the service.
+type UnimplementedEchoServiceAsync struct{}
+
+func (UnimplementedEchoServiceAsync) Echo(ctrl frog.RpcController, in *ProtoEchoRequest, out *ProtoEchoResponse) {
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "method Echo not implemented"))
+}
+
+func (UnimplementedEchoServiceAsync) Echo2(ctrl frog.RpcController, in *ProtoEchoRequest, out *ProtoEchoResponse) {
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "method Echo2 not implemented"))
+}
+
+func RegisterEchoServiceAsync(service EchoServiceAsync, register frog.MethodsRegister) error {
+	return frog.RegisterServiceHandlers(EchoService_ServiceDesc, service, register, _EchoServiceAsync_Handlers)
+}
+
+var _EchoServiceAsync_Handlers = &frog.ServiceHandlers{
+	AsyncDispatch: _EchoServiceAsync_Dispatch,
+	NewRequest:    _EchoService_NewRequest,
+	NewResponse:   _EchoService_NewResponse,
+}
+
+func _EchoServiceAsync_Dispatch(service interface{}, index int, ctrl frog.RpcController, in protoV2.Message, out protoV2.Message) {
+	switch index {
+	case 0:
+		service.(EchoServiceAsync).Echo(ctrl, proto.MessageV1(in).(*ProtoEchoRequest), proto.MessageV1(out).(*ProtoEchoResponse))
+		return
+	case 1:
+		service.(EchoServiceAsync).Echo2(ctrl, proto.MessageV1(in).(*ProtoEchoRequest), proto.MessageV1(out).(*ProtoEchoResponse))
+		return
+	}
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "EchoService has no unary method %d", index))
 }
 
 var (
@@ -97,7 +217,7 @@ var (
 
 func init() {
 	frog.GenerateServiceDesc(fileDescriptor1)
-	EchoService_ServiceDesc = frog.ServiceDescriptor("EchoService_ServiceDesc")
+	EchoService_ServiceDesc = frog.ServiceDescriptor("EchoService")
 }
 
 func init() { proto.RegisterFile("echo_service.proto", fileDescriptor1) }
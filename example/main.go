@@ -3,16 +3,22 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"log"
 	"time"
 
-	proto "github.com/golang/protobuf/proto"
 	"github.com/yplusplus/frog"
+	"google.golang.org/protobuf/proto"
 )
 
 var (
+	async = flag.Bool("async", false, "serve the service asynchronously")
+
 	methods = make([]*frog.RpcMethod, 0)
 	channel frog.RpcChannel
+
+	// timeouts of the calls of channel
+	timeouts = frog.NewTimerWheel(10*time.Millisecond, 256)
 )
 
 func registerMethods(method []*frog.RpcMethod) error {
@@ -32,10 +38,37 @@ func (impl *EchoServiceImpl) Echo2(ctx context.Context, in *ProtoEchoRequest, ou
 	return nil
 }
 
+// AsyncEchoServiceImpl serves the service asynchronously, and replies from
+// other goroutines
+type AsyncEchoServiceImpl struct {
+	UnimplementedEchoServiceAsync
+}
+
+func (impl *AsyncEchoServiceImpl) Echo(ctrl frog.RpcController, in *ProtoEchoRequest, out *ProtoEchoResponse) {
+	go func() {
+		out.Text = proto.String(*in.Text)
+		ctrl.Done(nil)
+	}()
+}
+
+func (impl *AsyncEchoServiceImpl) Echo2(ctrl frog.RpcController, in *ProtoEchoRequest, out *ProtoEchoResponse) {
+	go func() {
+		if ctrl.IsCanceled() {
+			return
+		}
+		out.Text = proto.String(*in.Text)
+		ctrl.Done(nil)
+	}()
+}
+
 type Channel int
 
 func (_ *Channel) Go(method *frog.MethodDesc, ctx context.Context, request proto.Message, response proto.Message) frog.RpcCall {
-	call := frog.NewDefaultCall(request, response)
+	call := frog.NewDefaultCallWithContext(ctx, request, response)
+	if _, ok := ctx.Deadline(); !ok {
+		// calls without deadline time out after a second
+		call.CancelAfter(timeouts, time.Second)
+	}
 
 	var rpcMeth *frog.RpcMethod
 	for _, meth := range methods {
@@ -49,43 +82,30 @@ func (_ *Channel) Go(method *frog.MethodDesc, ctx context.Context, request proto
 		return call
 	}
 
-	// async invoke
-	go func() {
-		time.Sleep(time.Second * 5)
-		err := frog.CallMethod(rpcMeth, ctx, request, response)
-		call.Close(err)
-	}()
-
-	if _, ok := ctx.Deadline(); ok {
-		go func() {
-			select {
-			case <-call.Done():
-				// already close, do nothing
-			case <-ctx.Done():
-				call.Close(errors.New("request timeout"))
-			}
-		}()
-	} else {
-		go func() {
-			t := time.NewTimer(time.Second)
-			select {
-			case <-call.Done():
-				// already closed, stop the timer
-				t.Stop()
-			case <-t.C:
-				call.Close(errors.New("request timeout"))
-			}
-		}()
-	}
+	// async invoke on a slow server, aborted if the call is canceled
+	t := time.AfterFunc(time.Second*5, func() {
+		served := frog.GoMethod(rpcMeth, ctx, request, response)
+		frog.OnDone(served, func(served frog.RpcCall) {
+			call.Close(served.Error())
+		})
+	})
+	call.OnCancel(func(err error) {
+		t.Stop()
+	})
 
 	return call
 }
 
 func main() {
+	flag.Parse()
+
 	// implement service
-	impl := new(EchoServiceImpl)
 	var err error
-	err = RegisterEchoService(impl, registerMethods)
+	if *async {
+		err = RegisterEchoServiceAsync(new(AsyncEchoServiceImpl), registerMethods)
+	} else {
+		err = RegisterEchoService(new(EchoServiceImpl), registerMethods)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -99,7 +119,8 @@ func main() {
 
 	// make a sync rpc
 	log.Println("begin a sync rpc")
-	ctx, _ := context.WithTimeout(context.Background(), time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
 	err = stub.Echo(ctx, &request, &response)
 	if err != nil {
 		log.Println(err)
@@ -110,27 +131,25 @@ func main() {
 	}
 	log.Println("end a sync rpc")
 
-	// make a async rpc
-	log.Println("begin a async rpc")
+	// make async rpcs
+	log.Println("begin async rpcs")
+	var response2 ProtoEchoResponse
 	call := stub.AsyncEcho(context.TODO(), &request, &response)
+	call2 := stub.AsyncEcho2(context.TODO(), &request, &response2)
 
-	// wating for response
-loop:
-	for {
-		select {
-		case <-call.Done():
-			log.Println("got a async call's response")
-			break loop
-		case <-time.Tick(time.Second):
-			log.Println("wating for a async call's reponse")
-		}
+	// wating for responses
+	if err := frog.WaitAll(context.TODO(), call, call2); err != nil {
+		log.Println(err)
 	}
-	if call.Error() != nil {
-		log.Println(call.Error())
-	} else if request.GetText() != response.GetText() {
-		log.Println("Text not match:", request.GetText(), "vs", response.GetText())
-	} else {
-		log.Println(request.GetText(), response.GetText())
+	for _, call := range []*frog.TypedCall[*ProtoEchoResponse]{call, call2} {
+		switch {
+		case call.Error() != nil:
+			// reported by WaitAll
+		case request.GetText() != call.Out().GetText():
+			log.Println("Text not match:", request.GetText(), "vs", call.Out().GetText())
+		default:
+			log.Println(request.GetText(), call.Out().GetText())
+		}
 	}
-	log.Println("end a async rpc")
+	log.Println("end async rpcs")
 }
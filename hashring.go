@@ -0,0 +1,272 @@
+package frog
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	defaultReplicas   = 100
+	defaultLoadFactor = 1.25
+)
+
+// KeyFunc extracts the hash key from a request. ok is false if the
+// request carries no key.
+type KeyFunc func(request proto.Message) (key string, ok bool)
+
+// ConsistentHashConfig configures a ConsistentHashPolicy.
+type ConsistentHashConfig struct {
+	// Replicas is the number of virtual nodes per backend, 100 by default.
+	Replicas int
+
+	// LoadFactor bounds the in-flight calls of every backend to LoadFactor
+	// times the average. 1.25 by default, and values below 1 disable bounding.
+	LoadFactor float64
+
+	// FieldPaths maps the full name of a method, as returned by
+	// MethodDesc.FullName, e.g. "/pkg.Service/Method", to the dotted path of
+	// the request field used as hash key, e.g. "user.id". Generated code
+	// declares these names as Xxx_Yyy_FullMethodName constants.
+	// NewConsistentHashPolicy panics on names of no registered method,
+	// such as "Service.Method" names of older releases.
+	FieldPaths map[string]string
+
+	// Option is an optional string method option holding the field path.
+	// It is consulted for methods not listed in FieldPaths.
+	Option protoreflect.ExtensionType
+
+	// KeyFuncs maps the full name of a method to a custom key extractor.
+	// It takes precedence over FieldPaths and Option.
+	KeyFuncs map[string]KeyFunc
+}
+
+type ringNode struct {
+	hash uint64
+	addr string
+}
+
+// ConsistentHashPolicy routes calls carrying the same key to the same backend,
+// using a consistent hash ring with virtual nodes and bounded load.
+// Calls without a key go to the least loaded backend.
+type ConsistentHashPolicy struct {
+	config ConsistentHashConfig
+
+	mu    sync.Mutex // protect following fields
+	ring  []ringNode
+	loads map[string]int
+	total int
+	keys  map[*MethodDesc]KeyFunc
+}
+
+func NewConsistentHashPolicy(config ConsistentHashConfig) *ConsistentHashPolicy {
+	for name := range config.FieldPaths {
+		checkMethodName("FieldPaths", name)
+	}
+	for name := range config.KeyFuncs {
+		checkMethodName("KeyFuncs", name)
+	}
+	if config.Replicas <= 0 {
+		config.Replicas = defaultReplicas
+	}
+	if config.LoadFactor == 0 {
+		config.LoadFactor = defaultLoadFactor
+	}
+	return &ConsistentHashPolicy{
+		config: config,
+		loads:  make(map[string]int),
+		keys:   make(map[*MethodDesc]KeyFunc),
+	}
+}
+
+// checkMethodName panics if name, a key of the config field, is not the full
+// name of a registered method, so that misspelled keys do not go unnoticed.
+func checkMethodName(field, name string) {
+	if !strings.HasPrefix(name, "/") || LookupMethod(name) == nil {
+		panic(fmt.Sprintf("frog: %s key %q is not the full name of a registered method, e.g. \"/pkg.Service/Method\"", field, name))
+	}
+}
+
+func (p *ConsistentHashPolicy) Update(addrs []string) {
+	ring := make([]ringNode, 0, len(addrs)*p.config.Replicas)
+	for _, addr := range addrs {
+		for i := 0; i < p.config.Replicas; i++ {
+			ring = append(ring, ringNode{hashKey(addr + "#" + strconv.Itoa(i)), addr})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+
+	p.mu.Lock()
+	p.ring = ring
+	loads := make(map[string]int, len(addrs))
+	for _, addr := range addrs {
+		loads[addr] = p.loads[addr]
+	}
+	p.loads = loads
+	p.mu.Unlock()
+}
+
+func (p *ConsistentHashPolicy) Pick(method *MethodDesc, ctx context.Context, request proto.Message) (string, func(error), error) {
+	key, hasKey := p.keyFunc(method)(request)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.ring) == 0 {
+		return "", nil, ErrNoBackend
+	}
+
+	var addr string
+	if hasKey {
+		addr = p.lookup(hashKey(key))
+	} else {
+		addr = p.leastLoaded()
+	}
+
+	p.loads[addr]++
+	p.total++
+	var once sync.Once
+	done := func(error) {
+		once.Do(func() {
+			p.mu.Lock()
+			if _, ok := p.loads[addr]; ok {
+				p.loads[addr]--
+			}
+			p.total--
+			p.mu.Unlock()
+		})
+	}
+	return addr, done, nil
+}
+
+// lookup walks the ring clockwise from h and returns the first backend
+// under the load limit.
+func (p *ConsistentHashPolicy) lookup(h uint64) string {
+	i := sort.Search(len(p.ring), func(i int) bool { return p.ring[i].hash >= h })
+	if p.config.LoadFactor < 1 {
+		return p.ring[i%len(p.ring)].addr
+	}
+
+	limit := int(math.Ceil(p.config.LoadFactor * float64(p.total+1) / float64(len(p.loads))))
+	for j := 0; j < len(p.ring); j++ {
+		node := p.ring[(i+j)%len(p.ring)]
+		if p.loads[node.addr] < limit {
+			return node.addr
+		}
+	}
+	return p.ring[i%len(p.ring)].addr
+}
+
+func (p *ConsistentHashPolicy) leastLoaded() string {
+	var addr string
+	min := math.MaxInt32
+	for a, load := range p.loads {
+		if load < min || (load == min && a < addr) {
+			addr, min = a, load
+		}
+	}
+	return addr
+}
+
+// keyFunc returns the cached key extractor of method.
+func (p *ConsistentHashPolicy) keyFunc(method *MethodDesc) KeyFunc {
+	p.mu.Lock()
+	fn, ok := p.keys[method]
+	p.mu.Unlock()
+	if ok {
+		return fn
+	}
+
+	fn = p.newKeyFunc(method)
+	p.mu.Lock()
+	p.keys[method] = fn
+	p.mu.Unlock()
+	return fn
+}
+
+func (p *ConsistentHashPolicy) newKeyFunc(method *MethodDesc) KeyFunc {
+	name := method.FullName()
+	if fn, ok := p.config.KeyFuncs[name]; ok {
+		return fn
+	}
+	if path, ok := p.config.FieldPaths[name]; ok {
+		return FieldKeyFunc(path)
+	}
+	if p.config.Option != nil {
+		if path, ok := MethodOption[string](method, p.config.Option); ok && path != "" {
+			return FieldKeyFunc(path)
+		}
+	}
+	return noKey
+}
+
+func noKey(proto.Message) (string, bool) {
+	return "", false
+}
+
+// FieldKeyFunc returns a KeyFunc which reads the request field at the
+// dotted path, where each element is a proto field name.
+func FieldKeyFunc(path string) KeyFunc {
+	names := strings.Split(path, ".")
+	return func(request proto.Message) (string, bool) {
+		if request == nil {
+			return "", false
+		}
+		m := request.ProtoReflect()
+		for i, name := range names {
+			fd := m.Descriptor().Fields().ByName(protoreflect.Name(name))
+			if fd == nil || fd.IsList() || fd.IsMap() {
+				return "", false
+			}
+			if fd.HasPresence() && !m.Has(fd) {
+				return "", false
+			}
+			if i == len(names)-1 {
+				return formatKey(fd, m.Get(fd))
+			}
+			if fd.Message() == nil {
+				return "", false
+			}
+			m = m.Get(fd).Message()
+		}
+		return "", false
+	}
+}
+
+func formatKey(fd protoreflect.FieldDescriptor, v protoreflect.Value) (string, bool) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return v.String(), true
+	case protoreflect.BytesKind:
+		return string(v.Bytes()), true
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), true
+		}
+		return strconv.Itoa(int(v.Enum())), true
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return "", false
+	}
+	return fmt.Sprint(v.Interface()), true
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// fnv alone spreads short similar keys poorly, finalize it as murmur3 does
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
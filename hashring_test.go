@@ -0,0 +1,184 @@
+package frog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// countingChannel completes every call at once, and counts calls per backend.
+type countingChannel struct {
+	addr   string
+	mu     *sync.Mutex
+	counts map[string]int
+}
+
+func (c *countingChannel) Go(method *MethodDesc, ctx context.Context, request proto.Message, response proto.Message) RpcCall {
+	c.mu.Lock()
+	c.counts[c.addr]++
+	c.mu.Unlock()
+	call := NewDefaultCall(request, response)
+	call.Close(nil)
+	return call
+}
+
+func newCountingDialer() (Dialer, map[string]int) {
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	return func(addr string) (RpcChannel, error) {
+		return &countingChannel{addr, &mu, counts}, nil
+	}, counts
+}
+
+func routeRequest(key string) *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{Options: &descriptorpb.FileOptions{JavaPackage: proto.String(key)}}
+}
+
+func TestConsistentHashFieldPath(t *testing.T) {
+	dial, counts := newCountingDialer()
+	route := testService.Method(testRoute)
+	p := NewConsistentHashPolicy(ConsistentHashConfig{
+		FieldPaths: map[string]string{route.FullName(): "options.java_package"},
+	})
+	bc, err := NewBalancingChannel(dial, p, "a", "b", "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Close()
+
+	for i := 0; i < 50; i++ {
+		<-bc.Go(route, context.Background(), routeRequest("key"), nil).Done()
+	}
+	if len(counts) != 1 {
+		t.Fatalf("calls of one key went to %v", counts)
+	}
+	for i := 0; i < 300; i++ {
+		<-bc.Go(route, context.Background(), routeRequest(fmt.Sprint(i)), nil).Done()
+	}
+	if len(counts) != 3 {
+		t.Fatalf("calls of many keys went to %v", counts)
+	}
+}
+
+func TestConsistentHashMethodFullName(t *testing.T) {
+	// a service of the same name in another package is not configured
+	other := registerTestService("frog.test.other", "frog/test_other.proto")
+	route := testService.Method(testRoute)
+	p := NewConsistentHashPolicy(ConsistentHashConfig{
+		FieldPaths: map[string]string{route.FullName(): "options.java_package"},
+		KeyFuncs: map[string]KeyFunc{
+			testService.Method(testEcho).FullName(): func(proto.Message) (string, bool) { return "echo", true },
+		},
+	})
+
+	if key, ok := p.keyFunc(route)(routeRequest("k")); !ok || key != "k" {
+		t.Errorf("key of %s = %q, %v, want k", route.FullName(), key, ok)
+	}
+	if key, ok := p.keyFunc(other.Method(testRoute))(routeRequest("k")); ok {
+		t.Errorf("key of %s = %q, want none", other.Method(testRoute).FullName(), key)
+	}
+	if key, ok := p.keyFunc(testService.Method(testEcho))(nil); !ok || key != "echo" {
+		t.Errorf("key of KeyFuncs = %q, %v, want echo", key, ok)
+	}
+	if _, ok := p.keyFunc(route)(&descriptorpb.FileDescriptorProto{}); ok {
+		t.Error("key of a request without the field")
+	}
+}
+
+func TestConsistentHashRemap(t *testing.T) {
+	p := NewConsistentHashPolicy(ConsistentHashConfig{LoadFactor: -1})
+	lookup := func(key string) string {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.lookup(hashKey(key))
+	}
+	const keys = 1000
+	p.Update([]string{"a", "b", "c", "d"})
+	before := make([]string, keys)
+	for i := range before {
+		before[i] = lookup(fmt.Sprint(i))
+	}
+	p.Update([]string{"a", "b", "c", "d", "e"})
+	moved := 0
+	for i, addr := range before {
+		now := lookup(fmt.Sprint(i))
+		if now != addr {
+			moved++
+			if now != "e" {
+				t.Fatalf("key %d moved from %s to %s, not to the new backend", i, addr, now)
+			}
+		}
+	}
+	// about a fifth of the keys move to the new backend
+	if moved < keys/10 || moved > keys*3/10 {
+		t.Errorf("%d of %d keys moved", moved, keys)
+	}
+}
+
+func TestConsistentHashBoundedLoad(t *testing.T) {
+	p := NewConsistentHashPolicy(ConsistentHashConfig{
+		LoadFactor: 1.25,
+		KeyFuncs: map[string]KeyFunc{
+			testService.Method(testEcho).FullName(): func(proto.Message) (string, bool) { return "hot", true },
+		},
+	})
+	p.Update([]string{"a", "b", "c", "d"})
+
+	// in-flight calls of one hot key spill over to other backends
+	picked := make(map[string]int)
+	var dones []func(error)
+	for i := 0; i < 40; i++ {
+		addr, done, err := p.Pick(testService.Method(testEcho), context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[addr]++
+		dones = append(dones, done)
+	}
+	for addr, n := range picked {
+		if n > 13 {
+			t.Errorf("backend %s has %d of 40 calls, want at most 13", addr, n)
+		}
+	}
+
+	// the key returns to its backend once the load is gone
+	for _, done := range dones {
+		done(nil)
+		done(nil) // done twice is harmless
+	}
+	first, done, _ := p.Pick(testService.Method(testEcho), context.Background(), nil)
+	done(nil)
+	for i := 0; i < 10; i++ {
+		addr, done, _ := p.Pick(testService.Method(testEcho), context.Background(), nil)
+		done(nil)
+		if addr != first {
+			t.Fatalf("idle key moved from %s to %s", first, addr)
+		}
+	}
+	if p.total != 0 {
+		t.Errorf("%d calls in flight after all are done", p.total)
+	}
+}
+
+func TestConsistentHashRejectsUnknownMethods(t *testing.T) {
+	echo := testService.Method(testEcho).FullName()
+	for _, config := range []ConsistentHashConfig{
+		{FieldPaths: map[string]string{"Test.Route": "options.java_package"}},
+		{FieldPaths: map[string]string{strings.TrimPrefix(echo, "/"): "options.java_package"}},
+		{KeyFuncs: map[string]KeyFunc{echo + "2": nil}},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("config %+v accepted", config)
+				}
+			}()
+			NewConsistentHashPolicy(config)
+		}()
+	}
+}
@@ -0,0 +1,435 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: health/health.proto
+
+package health
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+import (
+	"context"
+	frog "github.com/yplusplus/frog"
+	protoV2 "google.golang.org/protobuf/proto"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type HealthCheckResponse_ServingStatus int32
+
+const (
+	HealthCheckResponse_UNKNOWN         HealthCheckResponse_ServingStatus = 0
+	HealthCheckResponse_SERVING         HealthCheckResponse_ServingStatus = 1
+	HealthCheckResponse_NOT_SERVING     HealthCheckResponse_ServingStatus = 2
+	HealthCheckResponse_SERVICE_UNKNOWN HealthCheckResponse_ServingStatus = 3
+)
+
+var HealthCheckResponse_ServingStatus_name = map[int32]string{
+	0: "UNKNOWN",
+	1: "SERVING",
+	2: "NOT_SERVING",
+	3: "SERVICE_UNKNOWN",
+}
+
+var HealthCheckResponse_ServingStatus_value = map[string]int32{
+	"UNKNOWN":         0,
+	"SERVING":         1,
+	"NOT_SERVING":     2,
+	"SERVICE_UNKNOWN": 3,
+}
+
+func (x HealthCheckResponse_ServingStatus) String() string {
+	return proto.EnumName(HealthCheckResponse_ServingStatus_name, int32(x))
+}
+
+func (HealthCheckResponse_ServingStatus) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_e55f18a46a9fb2dc, []int{1, 0}
+}
+
+type HealthCheckRequest struct {
+	// Service name, empty means the whole server.
+	Service              string   `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthCheckRequest) Reset()         { *m = HealthCheckRequest{} }
+func (m *HealthCheckRequest) String() string { return proto.CompactTextString(m) }
+func (*HealthCheckRequest) ProtoMessage()    {}
+func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e55f18a46a9fb2dc, []int{0}
+}
+
+func (m *HealthCheckRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthCheckRequest.Unmarshal(m, b)
+}
+func (m *HealthCheckRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthCheckRequest.Marshal(b, m, deterministic)
+}
+func (m *HealthCheckRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthCheckRequest.Merge(m, src)
+}
+func (m *HealthCheckRequest) XXX_Size() int {
+	return xxx_messageInfo_HealthCheckRequest.Size(m)
+}
+func (m *HealthCheckRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthCheckRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthCheckRequest proto.InternalMessageInfo
+
+func (m *HealthCheckRequest) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+type HealthCheckResponse struct {
+	Status               HealthCheckResponse_ServingStatus `protobuf:"varint,1,opt,name=status,proto3,enum=frog.health.HealthCheckResponse_ServingStatus" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                          `json:"-"`
+	XXX_unrecognized     []byte                            `json:"-"`
+	XXX_sizecache        int32                             `json:"-"`
+}
+
+func (m *HealthCheckResponse) Reset()         { *m = HealthCheckResponse{} }
+func (m *HealthCheckResponse) String() string { return proto.CompactTextString(m) }
+func (*HealthCheckResponse) ProtoMessage()    {}
+func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e55f18a46a9fb2dc, []int{1}
+}
+
+func (m *HealthCheckResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthCheckResponse.Unmarshal(m, b)
+}
+func (m *HealthCheckResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthCheckResponse.Marshal(b, m, deterministic)
+}
+func (m *HealthCheckResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthCheckResponse.Merge(m, src)
+}
+func (m *HealthCheckResponse) XXX_Size() int {
+	return xxx_messageInfo_HealthCheckResponse.Size(m)
+}
+func (m *HealthCheckResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthCheckResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthCheckResponse proto.InternalMessageInfo
+
+func (m *HealthCheckResponse) GetStatus() HealthCheckResponse_ServingStatus {
+	if m != nil {
+		return m.Status
+	}
+	return HealthCheckResponse_UNKNOWN
+}
+
+type HealthWatchRequest struct {
+	Service string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	// Watch returns as soon as the status differs from last_status.
+	LastStatus HealthCheckResponse_ServingStatus `protobuf:"varint,2,opt,name=last_status,json=lastStatus,proto3,enum=frog.health.HealthCheckResponse_ServingStatus" json:"last_status,omitempty"`
+	// Maximum time to wait for a change, in milliseconds.
+	TimeoutMs            uint32   `protobuf:"varint,3,opt,name=timeout_ms,json=timeoutMs,proto3" json:"timeout_ms,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *HealthWatchRequest) Reset()         { *m = HealthWatchRequest{} }
+func (m *HealthWatchRequest) String() string { return proto.CompactTextString(m) }
+func (*HealthWatchRequest) ProtoMessage()    {}
+func (*HealthWatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_e55f18a46a9fb2dc, []int{2}
+}
+
+func (m *HealthWatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_HealthWatchRequest.Unmarshal(m, b)
+}
+func (m *HealthWatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_HealthWatchRequest.Marshal(b, m, deterministic)
+}
+func (m *HealthWatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_HealthWatchRequest.Merge(m, src)
+}
+func (m *HealthWatchRequest) XXX_Size() int {
+	return xxx_messageInfo_HealthWatchRequest.Size(m)
+}
+func (m *HealthWatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_HealthWatchRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_HealthWatchRequest proto.InternalMessageInfo
+
+func (m *HealthWatchRequest) GetService() string {
+	if m != nil {
+		return m.Service
+	}
+	return ""
+}
+
+func (m *HealthWatchRequest) GetLastStatus() HealthCheckResponse_ServingStatus {
+	if m != nil {
+		return m.LastStatus
+	}
+	return HealthCheckResponse_UNKNOWN
+}
+
+func (m *HealthWatchRequest) GetTimeoutMs() uint32 {
+	if m != nil {
+		return m.TimeoutMs
+	}
+	return 0
+}
+
+func init() {
+	proto.RegisterEnum("frog.health.HealthCheckResponse_ServingStatus", HealthCheckResponse_ServingStatus_name, HealthCheckResponse_ServingStatus_value)
+	proto.RegisterType((*HealthCheckRequest)(nil), "frog.health.HealthCheckRequest")
+	proto.RegisterType((*HealthCheckResponse)(nil), "frog.health.HealthCheckResponse")
+	proto.RegisterType((*HealthWatchRequest)(nil), "frog.health.HealthWatchRequest")
+}
+
+func init() { proto.RegisterFile("health/health.proto", fileDescriptor_e55f18a46a9fb2dc) }
+
+var fileDescriptor_e55f18a46a9fb2dc = []byte{
+	// 306 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x92, 0xdf, 0x6a, 0x83, 0x30,
+	0x14, 0x87, 0x97, 0x96, 0x59, 0x7a, 0xa4, 0x6b, 0x89, 0x37, 0x32, 0x18, 0x13, 0xaf, 0x7a, 0x15,
+	0xa1, 0x7b, 0x83, 0x95, 0xee, 0x2f, 0x53, 0xd0, 0x6d, 0x85, 0xdd, 0x88, 0x95, 0x4c, 0x65, 0xda,
+	0x38, 0x13, 0x07, 0x7b, 0x99, 0xb1, 0x27, 0xd8, 0x33, 0x8e, 0x44, 0x85, 0x16, 0x3a, 0x84, 0x5d,
+	0x88, 0x9e, 0x23, 0xdf, 0x97, 0xf3, 0x0b, 0x07, 0x8c, 0x94, 0x46, 0xb9, 0x48, 0x9d, 0xe6, 0x45,
+	0xca, 0x8a, 0x09, 0x86, 0xf5, 0xd7, 0x8a, 0x25, 0xa4, 0x69, 0xd9, 0x04, 0xf0, 0x8d, 0xfa, 0x5a,
+	0xa6, 0x34, 0x7e, 0xf3, 0xe9, 0x7b, 0x4d, 0xb9, 0xc0, 0x26, 0x8c, 0x38, 0xad, 0x3e, 0xb2, 0x98,
+	0x9a, 0xc8, 0x42, 0xf3, 0xb1, 0xdf, 0x95, 0xf6, 0x0f, 0x02, 0x63, 0x0f, 0xe0, 0x25, 0xdb, 0x72,
+	0x8a, 0xaf, 0x40, 0xe3, 0x22, 0x12, 0x35, 0x57, 0xc0, 0xc9, 0x82, 0x90, 0x9d, 0x53, 0xc8, 0x01,
+	0x82, 0x04, 0xd2, 0xb8, 0x4d, 0x02, 0x45, 0xf9, 0x2d, 0x6d, 0x7b, 0x30, 0xd9, 0xfb, 0x81, 0x75,
+	0x18, 0x3d, 0xb9, 0xf7, 0xae, 0xb7, 0x76, 0x67, 0x47, 0xb2, 0x08, 0x56, 0xfe, 0xf3, 0xad, 0x7b,
+	0x3d, 0x43, 0x78, 0x0a, 0xba, 0xeb, 0x3d, 0x86, 0x5d, 0x63, 0x80, 0x0d, 0x98, 0xaa, 0x62, 0xb9,
+	0x0a, 0x3b, 0x64, 0x68, 0x7f, 0xa1, 0x2e, 0xe1, 0x3a, 0x12, 0x71, 0xda, 0x9b, 0x10, 0x7b, 0xa0,
+	0xe7, 0x11, 0x17, 0x61, 0x1b, 0x67, 0xf0, 0xaf, 0x38, 0x20, 0x15, 0x6d, 0x82, 0x33, 0x00, 0x91,
+	0x15, 0x94, 0xd5, 0x22, 0x2c, 0xb8, 0x39, 0xb4, 0xd0, 0x7c, 0xe2, 0x8f, 0xdb, 0xce, 0x03, 0x5f,
+	0x7c, 0x23, 0xd0, 0x1a, 0x21, 0xbe, 0x83, 0x63, 0x25, 0xc5, 0xe7, 0x7f, 0x1f, 0xa7, 0xc6, 0x3f,
+	0xb5, 0xfa, 0xe6, 0x91, 0x2e, 0x15, 0xf8, 0xa0, 0x6b, 0xf7, 0x2a, 0xfa, 0x5d, 0x97, 0xf6, 0x8b,
+	0x95, 0x64, 0x22, 0xad, 0x37, 0x24, 0x66, 0x85, 0xf3, 0x59, 0xe6, 0x35, 0x97, 0x8f, 0x23, 0xb9,
+	0x76, 0xb7, 0x36, 0x9a, 0x5a, 0xae, 0x8b, 0xdf, 0x01, 0x00, 0xba, 0xd8, 0x11, 0x29, 0x73, 0x02,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+
+// Stub for Health service
+
+// Full names of the methods of Health service, as returned by MethodDesc.FullName.
+const (
+	Health_Check_FullMethodName = "/frog.health.Health/Check"
+	Health_Watch_FullMethodName = "/frog.health.Health/Watch"
+)
+
+// Health_Check_MethodDesc returns the descriptor of the Check method of Health service.
+func Health_Check_MethodDesc() *frog.MethodDesc {
+	return Health_ServiceDesc.Method(0)
+}
+
+// Health_Watch_MethodDesc returns the descriptor of the Watch method of Health service.
+func Health_Watch_MethodDesc() *frog.MethodDesc {
+	return Health_ServiceDesc.Method(1)
+}
+
+// HealthClient is the client API for Health service.
+//
+// Health is the health checking service every frog server exposes.
+type HealthClient interface {
+	// Check returns the serving status of a service.
+	Check(ctx context.Context, in *HealthCheckRequest, out *HealthCheckResponse) error
+	// AsyncCheck is the asynchronous version of Check.
+	//
+	// Check returns the serving status of a service.
+	AsyncCheck(ctx context.Context, in *HealthCheckRequest, out *HealthCheckResponse) *frog.TypedCall[*HealthCheckResponse]
+	// Watch is the polling API of status changes: it blocks until the status
+	// differs from the one the caller last saw, or the timeout expires.
+	Watch(ctx context.Context, in *HealthWatchRequest, out *HealthCheckResponse) error
+	// AsyncWatch is the asynchronous version of Watch.
+	//
+	// Watch is the polling API of status changes: it blocks until the status
+	// differs from the one the caller last saw, or the timeout expires.
+	AsyncWatch(ctx context.Context, in *HealthWatchRequest, out *HealthCheckResponse) *frog.TypedCall[*HealthCheckResponse]
+}
+
+type healthStub struct {
+	channel frog.RpcChannel
+}
+
+// NewHealthStub returns the HealthClient calling methods on channel.
+//
+// Health is the health checking service every frog server exposes.
+func NewHealthStub(channel frog.RpcChannel) HealthClient {
+	return &healthStub{channel}
+}
+
+// Check returns the serving status of a service.
+func (stub *healthStub) Check(ctx context.Context, in *HealthCheckRequest, out *HealthCheckResponse) error {
+	err := stub.Call(Health_Check_MethodDesc(), ctx, in, out)
+	return err
+}
+
+// AsyncCheck is the asynchronous version of Check.
+//
+// Check returns the serving status of a service.
+func (stub *healthStub) AsyncCheck(ctx context.Context, in *HealthCheckRequest, out *HealthCheckResponse) *frog.TypedCall[*HealthCheckResponse] {
+	return frog.NewTypedCall(stub.Go(Health_Check_MethodDesc(), ctx, in, out), out)
+}
+
+// Watch is the polling API of status changes: it blocks until the status
+// differs from the one the caller last saw, or the timeout expires.
+func (stub *healthStub) Watch(ctx context.Context, in *HealthWatchRequest, out *HealthCheckResponse) error {
+	err := stub.Call(Health_Watch_MethodDesc(), ctx, in, out)
+	return err
+}
+
+// AsyncWatch is the asynchronous version of Watch.
+//
+// Watch is the polling API of status changes: it blocks until the status
+// differs from the one the caller last saw, or the timeout expires.
+func (stub *healthStub) AsyncWatch(ctx context.Context, in *HealthWatchRequest, out *HealthCheckResponse) *frog.TypedCall[*HealthCheckResponse] {
+	return frog.NewTypedCall(stub.Go(Health_Watch_MethodDesc(), ctx, in, out), out)
+}
+
+func (stub *healthStub) Call(method *frog.MethodDesc, ctx context.Context, in proto.Message, out proto.Message) error {
+	call := stub.channel.Go(method, ctx, proto.MessageV2(in), proto.MessageV2(out))
+	<-call.Done()
+	return call.Error()
+}
+
+func (stub *healthStub) Go(method *frog.MethodDesc, ctx context.Context, in proto.Message, out proto.Message) frog.RpcCall {
+	return stub.channel.Go(method, ctx, proto.MessageV2(in), proto.MessageV2(out))
+}
+
+// Health is the server API for Health service.
+//
+// Health is the health checking service every frog server exposes.
+type Health interface {
+	// Check returns the serving status of a service.
+	Check(context.Context, *HealthCheckRequest, *HealthCheckResponse) error
+	// Watch is the polling API of status changes: it blocks until the status
+	// differs from the one the caller last saw, or the timeout expires.
+	Watch(context.Context, *HealthWatchRequest, *HealthCheckResponse) error
+}
+
+// UnimplementedHealth should be embedded by implementations of Health,
+// so they keep compiling and registering when methods are added to the service.
+type UnimplementedHealth struct{}
+
+func (UnimplementedHealth) Check(ctx context.Context, in *HealthCheckRequest, out *HealthCheckResponse) error {
+	return frog.Errorf(frog.Unimplemented, "method Check not implemented")
+}
+
+func (UnimplementedHealth) Watch(ctx context.Context, in *HealthWatchRequest, out *HealthCheckResponse) error {
+	return frog.Errorf(frog.Unimplemented, "method Watch not implemented")
+}
+
+func RegisterHealth(service Health, register frog.MethodsRegister) error {
+	return frog.RegisterServiceHandlers(Health_ServiceDesc, service, register, _Health_Handlers)
+}
+
+var _Health_Handlers = &frog.ServiceHandlers{
+	Dispatch:    _Health_Dispatch,
+	NewRequest:  _Health_NewRequest,
+	NewResponse: _Health_NewResponse,
+}
+
+func _Health_Dispatch(service interface{}, index int, ctx context.Context, in protoV2.Message, out protoV2.Message) error {
+	switch index {
+	case 0:
+		return service.(Health).Check(ctx, proto.MessageV1(in).(*HealthCheckRequest), proto.MessageV1(out).(*HealthCheckResponse))
+	case 1:
+		return service.(Health).Watch(ctx, proto.MessageV1(in).(*HealthWatchRequest), proto.MessageV1(out).(*HealthCheckResponse))
+	}
+	return frog.Errorf(frog.Unimplemented, "Health has no unary method %d", index)
+}
+
+func _Health_NewRequest(index int) protoV2.Message {
+	switch index {
+	case 0:
+		return proto.MessageV2(new(HealthCheckRequest))
+	case 1:
+		return proto.MessageV2(new(HealthWatchRequest))
+	}
+	return nil
+}
+
+func _Health_NewResponse(index int) protoV2.Message {
+	switch index {
+	case 0:
+		return proto.MessageV2(new(HealthCheckResponse))
+	case 1:
+		return proto.MessageV2(new(HealthCheckResponse))
+	}
+	return nil
+}
+
+// HealthAsync is the server API for Health service served asynchronously:
+// unary methods may return at once and complete the call later with ctrl.Done.
+//
+// Health is the health checking service every frog server exposes.
+type HealthAsync interface {
+	// Check returns the serving status of a service.
+	Check(frog.RpcController, *HealthCheckRequest, *HealthCheckResponse)
+	// Watch is the polling API of status changes: it blocks until the status
+	// differs from the one the caller last saw, or the timeout expires.
+	Watch(frog.RpcController, *HealthWatchRequest, *HealthCheckResponse)
+}
+
+// UnimplementedHealthAsync should be embedded by implementations of HealthAsync,
+// so they keep compiling and registering when methods are added to the service.
+type UnimplementedHealthAsync struct{}
+
+func (UnimplementedHealthAsync) Check(ctrl frog.RpcController, in *HealthCheckRequest, out *HealthCheckResponse) {
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "method Check not implemented"))
+}
+
+func (UnimplementedHealthAsync) Watch(ctrl frog.RpcController, in *HealthWatchRequest, out *HealthCheckResponse) {
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "method Watch not implemented"))
+}
+
+func RegisterHealthAsync(service HealthAsync, register frog.MethodsRegister) error {
+	return frog.RegisterServiceHandlers(Health_ServiceDesc, service, register, _HealthAsync_Handlers)
+}
+
+var _HealthAsync_Handlers = &frog.ServiceHandlers{
+	AsyncDispatch: _HealthAsync_Dispatch,
+	NewRequest:    _Health_NewRequest,
+	NewResponse:   _Health_NewResponse,
+}
+
+func _HealthAsync_Dispatch(service interface{}, index int, ctrl frog.RpcController, in protoV2.Message, out protoV2.Message) {
+	switch index {
+	case 0:
+		service.(HealthAsync).Check(ctrl, proto.MessageV1(in).(*HealthCheckRequest), proto.MessageV1(out).(*HealthCheckResponse))
+		return
+	case 1:
+		service.(HealthAsync).Watch(ctrl, proto.MessageV1(in).(*HealthWatchRequest), proto.MessageV1(out).(*HealthCheckResponse))
+		return
+	}
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "Health has no unary method %d", index))
+}
+
+var (
+	Health_ServiceDesc *frog.ServiceDesc
+)
+
+func init() {
+	frog.GenerateServiceDesc(fileDescriptor_e55f18a46a9fb2dc)
+	Health_ServiceDesc = frog.ServiceDescriptor("frog.health.Health")
+}
@@ -0,0 +1,298 @@
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	proto "github.com/golang/protobuf/proto"
+	"github.com/yplusplus/frog"
+	protoV2 "google.golang.org/protobuf/proto"
+)
+
+// registerCheck registers s with the generated handlers, or by reflection,
+// and returns its Check method.
+func registerCheck(t testing.TB, s *Server, reflection bool) *frog.RpcMethod {
+	var methods []*frog.RpcMethod
+	register := func(m []*frog.RpcMethod) error { methods = m; return nil }
+	var err error
+	if reflection {
+		err = frog.RegisterService(Health_ServiceDesc, s, register)
+	} else {
+		err = RegisterHealth(s, register)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range methods {
+		if m.Descriptor() == Health_Check_MethodDesc() {
+			return m
+		}
+	}
+	t.Fatal("no Check method registered")
+	return nil
+}
+
+func TestCheckDispatch(t *testing.T) {
+	s := NewServer()
+	s.SetServingStatus("frog.Echo", HealthCheckResponse_NOT_SERVING)
+	for _, reflection := range []bool{false, true} {
+		check := registerCheck(t, s, reflection)
+		in := proto.MessageV1(check.NewRequest()).(*HealthCheckRequest)
+		out := proto.MessageV1(check.NewResponse()).(*HealthCheckResponse)
+		in.Service = "frog.Echo"
+		if err := frog.CallMethod(check, context.Background(), proto.MessageV2(in), proto.MessageV2(out)); err != nil {
+			t.Fatal(err)
+		}
+		if out.Status != HealthCheckResponse_NOT_SERVING {
+			t.Errorf("status (reflection %v) = %v, want NOT_SERVING", reflection, out.Status)
+		}
+
+		in.Service = "frog.Unknown"
+		if err := frog.CallMethod(check, context.Background(), proto.MessageV2(in), proto.MessageV2(out)); frog.ErrorCode(err) != frog.NotFound {
+			t.Errorf("check of unknown service (reflection %v) = %v, want NotFound", reflection, err)
+		}
+	}
+}
+
+func BenchmarkCheck(b *testing.B) {
+	for _, bm := range []struct {
+		name       string
+		reflection bool
+	}{
+		{"Dispatch", false},
+		{"Reflection", true},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			check := registerCheck(b, NewServer(), bm.reflection)
+			ctx := context.Background()
+			in, out := proto.MessageV2(&HealthCheckRequest{}), proto.MessageV2(&HealthCheckResponse{})
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := frog.CallMethod(check, ctx, in, out); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// watch calls Watch in a goroutine and returns the channel of its result.
+func watch(s *Server, ctx context.Context, in *HealthWatchRequest) <-chan error {
+	out := new(HealthCheckResponse)
+	done := make(chan error, 1)
+	go func() {
+		err := s.Watch(ctx, in, out)
+		if err == nil && out.Status != HealthCheckResponse_SERVING {
+			err = frog.Errorf(frog.Unknown, "status %v", out.Status)
+		}
+		done <- err
+	}()
+	return done
+}
+
+func TestWatch(t *testing.T) {
+	s := NewServer()
+	s.SetServingStatus("frog.Echo", HealthCheckResponse_NOT_SERVING)
+
+	// a change of status ends the watch
+	done := watch(s, context.Background(), &HealthWatchRequest{Service: "frog.Echo", LastStatus: HealthCheckResponse_NOT_SERVING})
+	select {
+	case err := <-done:
+		t.Fatalf("watch returned %v before the status changed", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	s.SetServingStatus("frog.Echo", HealthCheckResponse_SERVING)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("watch = %v, want SERVING", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not return once the status changed")
+	}
+
+	// a status other than the last one returns at once
+	out := new(HealthCheckResponse)
+	if err := s.Watch(context.Background(), &HealthWatchRequest{Service: "frog.Unknown"}, out); err != nil || out.Status != HealthCheckResponse_SERVICE_UNKNOWN {
+		t.Errorf("watch of unknown service = %v, %v, want SERVICE_UNKNOWN", out.Status, err)
+	}
+
+	// the end of ctx ends the watch
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := s.Watch(ctx, &HealthWatchRequest{Service: "frog.Echo", LastStatus: HealthCheckResponse_SERVING}, out)
+	if frog.ErrorCode(err) != frog.DeadlineExceeded {
+		t.Errorf("watch after deadline = %v, want DeadlineExceeded", err)
+	}
+
+	// so does its timeout, with the current status
+	err = s.Watch(context.Background(), &HealthWatchRequest{Service: "frog.Echo", LastStatus: HealthCheckResponse_SERVING, TimeoutMs: 10}, out)
+	if err != nil || out.Status != HealthCheckResponse_SERVING {
+		t.Errorf("watch after timeout = %v, %v, want SERVING", out.Status, err)
+	}
+}
+
+func TestShutdownAndResume(t *testing.T) {
+	s := NewServer()
+	s.SetServingStatus("frog.Echo", HealthCheckResponse_SERVING)
+	s.SetServingStatus("frog.Other", HealthCheckResponse_NOT_SERVING)
+	status := func(service string) HealthCheckResponse_ServingStatus {
+		out := new(HealthCheckResponse)
+		if err := s.Check(context.Background(), &HealthCheckRequest{Service: service}, out); err != nil {
+			t.Fatal(err)
+		}
+		return out.Status
+	}
+
+	s.Shutdown()
+	for _, service := range []string{"", "frog.Echo", "frog.Other"} {
+		if got := status(service); got != HealthCheckResponse_NOT_SERVING {
+			t.Errorf("status of %q after Shutdown = %v, want NOT_SERVING", service, got)
+		}
+	}
+	s.SetServingStatus("frog.Echo", HealthCheckResponse_SERVING)
+	if got := status("frog.Echo"); got != HealthCheckResponse_NOT_SERVING {
+		t.Errorf("status set after Shutdown = %v, want it ignored", got)
+	}
+
+	s.Resume()
+	for _, service := range []string{"", "frog.Echo", "frog.Other"} {
+		if got := status(service); got != HealthCheckResponse_SERVING {
+			t.Errorf("status of %q after Resume = %v, want SERVING", service, got)
+		}
+	}
+}
+
+// serverChannel serves calls with the methods of a health server, and counts them.
+type serverChannel struct {
+	methods []*frog.RpcMethod
+	calls   *int32
+}
+
+func newServerChannel(t *testing.T, s *Server) *serverChannel {
+	c := &serverChannel{calls: new(int32)}
+	if err := RegisterHealth(s, func(m []*frog.RpcMethod) error { c.methods = m; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func (c *serverChannel) Go(method *frog.MethodDesc, ctx context.Context, request, response protoV2.Message) frog.RpcCall {
+	atomic.AddInt32(c.calls, 1)
+	call := frog.NewDefaultCall(request, response)
+	for _, m := range c.methods {
+		if m.Descriptor() == method {
+			call.Close(frog.CallMethod(m, ctx, request, response))
+			return call
+		}
+	}
+	call.Close(frog.Errorf(frog.Unimplemented, "no method %s", method.FullName()))
+	return call
+}
+
+func TestChecker(t *testing.T) {
+	s := NewServer()
+	channel := newServerChannel(t, s)
+	s.SetServingStatus("frog.Echo", HealthCheckResponse_SERVING)
+	s.SetServingStatus("frog.Down", HealthCheckResponse_NOT_SERVING)
+	for _, tc := range []struct {
+		service string
+		code    frog.Code
+	}{
+		{"", frog.OK},
+		{"frog.Echo", frog.OK},
+		{"frog.Down", frog.Unavailable},
+		{"frog.Unknown", frog.NotFound},
+	} {
+		if err := Checker(tc.service)(context.Background(), channel); frog.ErrorCode(err) != tc.code {
+			t.Errorf("check of %q = %v, want %v", tc.service, err, tc.code)
+		}
+	}
+}
+
+func TestBalancingChannelHealthCheck(t *testing.T) {
+	servers := map[string]*Server{"a": NewServer(), "b": NewServer()}
+	channels := make(map[string]*serverChannel)
+	for addr, s := range servers {
+		channels[addr] = newServerChannel(t, s)
+	}
+	bc, err := frog.NewBalancingChannel(func(addr string) (frog.RpcChannel, error) { return channels[addr], nil }, nil, "a", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Close()
+	bc.EnableHealthCheck(Checker(""), 5*time.Millisecond)
+
+	// callsTo makes calls through bc, and returns how many each backend got
+	callsTo := func() map[string]int32 {
+		before := map[string]int32{"a": atomic.LoadInt32(channels["a"].calls), "b": atomic.LoadInt32(channels["b"].calls)}
+		stub := NewHealthStub(bc)
+		for i := 0; i < 10; i++ {
+			stub.Check(context.Background(), &HealthCheckRequest{}, &HealthCheckResponse{})
+		}
+		return map[string]int32{"a": atomic.LoadInt32(channels["a"].calls) - before["a"], "b": atomic.LoadInt32(channels["b"].calls) - before["b"]}
+	}
+	waitFor := func(what string, cond func(map[string]int32) bool) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); ; {
+			if calls := callsTo(); cond(calls) {
+				return
+			} else if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s: calls %v", what, calls)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	servers["b"].Shutdown()
+	// health checks go on to every backend, calls only to the healthy one
+	waitFor("b to be left out", func(calls map[string]int32) bool { return calls["a"] >= 10 && calls["b"] < 10 && calls["b"] <= 2 })
+	servers["b"].Resume()
+	waitFor("b to be back", func(calls map[string]int32) bool { return calls["b"] >= 5 })
+}
+
+// checkOnly implements only Check, and embeds UnimplementedHealth for Watch.
+type checkOnly struct {
+	UnimplementedHealth
+}
+
+func (*checkOnly) Check(ctx context.Context, in *HealthCheckRequest, out *HealthCheckResponse) error {
+	out.Status = HealthCheckResponse_SERVING
+	return nil
+}
+
+func TestPartialImplementation(t *testing.T) {
+	for _, reflection := range []bool{false, true} {
+		var methods []*frog.RpcMethod
+		register := func(m []*frog.RpcMethod) error { methods = m; return nil }
+		var err error
+		if reflection {
+			err = frog.RegisterService(Health_ServiceDesc, new(checkOnly), register)
+		} else {
+			err = RegisterHealth(new(checkOnly), register)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range methods {
+			out := new(HealthCheckResponse)
+			err := frog.CallMethod(m, context.Background(), m.NewRequest(), proto.MessageV2(out))
+			switch m.Descriptor() {
+			case Health_Check_MethodDesc():
+				if err != nil || out.Status != HealthCheckResponse_SERVING {
+					t.Errorf("Check (reflection %v) = %v, %v, want SERVING", reflection, out.Status, err)
+				}
+			case Health_Watch_MethodDesc():
+				if frog.ErrorCode(err) != frog.Unimplemented {
+					t.Errorf("Watch (reflection %v) = %v, want Unimplemented", reflection, err)
+				}
+			}
+		}
+		if len(methods) != 2 {
+			t.Errorf("%d methods registered (reflection %v), want 2", len(methods), reflection)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package health implements the frog.health.Health service and
+// active health checks for the balancing channel.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yplusplus/frog"
+)
+
+const maxWatchTimeout = time.Minute
+
+// Server implements the Health service. The status of the whole server,
+// the empty service name, is SERVING after NewServer.
+type Server struct {
+	mu       sync.Mutex // protect following fields
+	statuses map[string]HealthCheckResponse_ServingStatus
+	changed  chan struct{} // closed and replaced on every status change
+	shutdown bool
+}
+
+func NewServer() *Server {
+	return &Server{
+		statuses: map[string]HealthCheckResponse_ServingStatus{"": HealthCheckResponse_SERVING},
+		changed:  make(chan struct{}),
+	}
+}
+
+// SetServingStatus sets the serving status of service and wakes up watchers.
+// It is ignored after Shutdown.
+func (s *Server) SetServingStatus(service string, status HealthCheckResponse_ServingStatus) {
+	s.mu.Lock()
+	if !s.shutdown {
+		s.setLocked(service, status)
+	}
+	s.mu.Unlock()
+}
+
+// Shutdown sets every service NOT_SERVING and ignores later status updates.
+// It is meant to be called before the server stops, to drain traffic.
+func (s *Server) Shutdown() {
+	s.mu.Lock()
+	s.shutdown = true
+	for service := range s.statuses {
+		s.setLocked(service, HealthCheckResponse_NOT_SERVING)
+	}
+	s.mu.Unlock()
+}
+
+// Resume sets every service SERVING and accepts status updates again.
+func (s *Server) Resume() {
+	s.mu.Lock()
+	s.shutdown = false
+	for service := range s.statuses {
+		s.setLocked(service, HealthCheckResponse_SERVING)
+	}
+	s.mu.Unlock()
+}
+
+func (s *Server) setLocked(service string, status HealthCheckResponse_ServingStatus) {
+	if old, ok := s.statuses[service]; ok && old == status {
+		return
+	}
+	s.statuses[service] = status
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Server) status(service string) (HealthCheckResponse_ServingStatus, bool, chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.statuses[service]
+	return status, ok, s.changed
+}
+
+// Check returns the serving status of the service, or a NotFound error for unknown services.
+func (s *Server) Check(ctx context.Context, in *HealthCheckRequest, out *HealthCheckResponse) error {
+	status, ok, _ := s.status(in.GetService())
+	if !ok {
+		return frog.Errorf(frog.NotFound, "unknown service %q", in.GetService())
+	}
+	out.Status = status
+	return nil
+}
+
+// Watch returns once the status of the service differs from in.LastStatus, or
+// the current status when the timeout expires. Unknown services are reported
+// as SERVICE_UNKNOWN, so they can be watched before they are registered.
+func (s *Server) Watch(ctx context.Context, in *HealthWatchRequest, out *HealthCheckResponse) error {
+	timeout := time.Duration(in.GetTimeoutMs()) * time.Millisecond
+	if timeout <= 0 || timeout > maxWatchTimeout {
+		timeout = maxWatchTimeout
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	for {
+		status, ok, changed := s.status(in.GetService())
+		if !ok {
+			status = HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		out.Status = status
+		if status != in.GetLastStatus() {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-t.C:
+			return nil
+		case <-ctx.Done():
+			return frog.FromContextError(ctx.Err())
+		}
+	}
+}
+
+// Checker returns a frog.HealthCheckFunc which calls Health.Check for service
+// and fails unless it is SERVING.
+func Checker(service string) frog.HealthCheckFunc {
+	return func(ctx context.Context, channel frog.RpcChannel) error {
+		var out HealthCheckResponse
+		err := NewHealthStub(channel).Check(ctx, &HealthCheckRequest{Service: service}, &out)
+		if err != nil {
+			return err
+		}
+		if out.Status != HealthCheckResponse_SERVING {
+			return frog.Errorf(frog.Unavailable, "service %q is %s", service, out.Status)
+		}
+		return nil
+	}
+}
+
+var _ Health = (*Server)(nil)
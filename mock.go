@@ -0,0 +1,122 @@
+package frog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// MockCall is a call recorded by a generated mock.
+type MockCall struct {
+	Method   string        // method name, e.g. "Echo"
+	Request  proto.Message // copy of the request, nil for client streaming methods
+	Response proto.Message // copy of the response, nil for streaming methods
+	Err      error
+}
+
+// MockRecorder keeps the hooks and the calls of a generated mock, and checks
+// expectations on the calls. It is embedded by generated mocks, and its zero
+// value is ready to use.
+type MockRecorder struct {
+	mu      sync.Mutex // protect following fields
+	hooks   map[string]interface{}
+	calls   []MockCall
+	expects map[string]int
+}
+
+// SetHook sets the function serving method. It is called from generated code.
+func (r *MockRecorder) SetHook(method string, fn interface{}) {
+	r.mu.Lock()
+	if r.hooks == nil {
+		r.hooks = make(map[string]interface{})
+	}
+	r.hooks[method] = fn
+	r.mu.Unlock()
+}
+
+// Hook returns the function serving method, nil if not set. It is called from generated code.
+func (r *MockRecorder) Hook(method string) interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.hooks[method]
+}
+
+// Record records a call of method. It is called from generated code.
+func (r *MockRecorder) Record(method string, request, response proto.Message, err error) {
+	call := MockCall{Method: method, Err: err}
+	if request != nil {
+		call.Request = proto.Clone(request)
+	}
+	if response != nil {
+		call.Response = proto.Clone(response)
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	r.mu.Unlock()
+}
+
+// Calls returns the calls of method in order, or all calls if method is empty.
+func (r *MockRecorder) Calls(method string) []MockCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var calls []MockCall
+	for _, call := range r.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Called returns the number of calls of method.
+func (r *MockRecorder) Called(method string) int {
+	return len(r.Calls(method))
+}
+
+// Expect expects method to be called exactly times times, checked by Verify.
+func (r *MockRecorder) Expect(method string, times int) {
+	r.mu.Lock()
+	if r.expects == nil {
+		r.expects = make(map[string]int)
+	}
+	r.expects[method] = times
+	r.mu.Unlock()
+}
+
+// Verify returns an error describing every unmet expectation in order of
+// method name, nil if all are met.
+func (r *MockRecorder) Verify() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, call := range r.calls {
+		counts[call.Method]++
+	}
+	methods := make([]string, 0, len(r.expects))
+	for method := range r.expects {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	var msgs []string
+	for _, method := range methods {
+		if times := r.expects[method]; counts[method] != times {
+			msgs = append(msgs, fmt.Sprintf("%s called %d times, expected %d", method, counts[method], times))
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("frog: mock expectations not met: %s", strings.Join(msgs, "; "))
+}
+
+// Reset forgets recorded calls and expectations, and keeps hooks.
+func (r *MockRecorder) Reset() {
+	r.mu.Lock()
+	r.calls = nil
+	r.expects = nil
+	r.mu.Unlock()
+}
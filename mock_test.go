@@ -0,0 +1,84 @@
+package frog
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestMockRecorderCalls(t *testing.T) {
+	var r MockRecorder
+	request := wrapperspb.String("hello")
+	r.Record("Echo", request, wrapperspb.String("hello"), nil)
+	r.Record("Upload", nil, nil, errors.New("broken"))
+	r.Record("Echo", wrapperspb.String("again"), nil, nil)
+
+	// requests are copied
+	request.Value = "changed"
+	calls := r.Calls("Echo")
+	if len(calls) != 2 {
+		t.Fatalf("calls of Echo = %d, want 2", len(calls))
+	}
+	if !proto.Equal(calls[0].Request, wrapperspb.String("hello")) || !proto.Equal(calls[1].Request, wrapperspb.String("again")) {
+		t.Errorf("requests of Echo = %v, %v, want hello, again", calls[0].Request, calls[1].Request)
+	}
+	if calls[1].Response != nil {
+		t.Errorf("response of the second Echo = %v, want nil", calls[1].Response)
+	}
+	if got := r.Calls(""); len(got) != 3 || got[1].Method != "Upload" || got[1].Err == nil {
+		t.Errorf("all calls = %v, want Echo, Upload failed, Echo", got)
+	}
+	if n := r.Called("Chat"); n != 0 {
+		t.Errorf("Chat called %d times, want 0", n)
+	}
+}
+
+func TestMockRecorderVerify(t *testing.T) {
+	var r MockRecorder
+	if err := r.Verify(); err != nil {
+		t.Errorf("verify without expectations: %v", err)
+	}
+
+	r.Expect("Echo", 1)
+	r.Expect("Chat", 2)
+	r.Expect("Upload", 0)
+	r.Record("Echo", nil, nil, nil)
+	r.Record("Upload", nil, nil, nil)
+	const want = "frog: mock expectations not met: Chat called 0 times, expected 2; Upload called 1 times, expected 0"
+	for i := 0; i < 10; i++ {
+		if err := r.Verify(); err == nil || err.Error() != want {
+			t.Fatalf("verify = %v, want %s", err, want)
+		}
+	}
+
+	r.Record("Chat", nil, nil, nil)
+	r.Record("Chat", nil, nil, nil)
+	r.Expect("Upload", 1)
+	if err := r.Verify(); err != nil {
+		t.Errorf("verify of met expectations: %v", err)
+	}
+}
+
+func TestMockRecorderReset(t *testing.T) {
+	var r MockRecorder
+	hook := func() {}
+	r.SetHook("Echo", hook)
+	r.Expect("Echo", 1)
+	r.Record("Chat", nil, nil, nil)
+	r.Reset()
+
+	if n := r.Called(""); n != 0 {
+		t.Errorf("%d calls after reset, want 0", n)
+	}
+	if err := r.Verify(); err != nil {
+		t.Errorf("verify after reset: %v", err)
+	}
+	if r.Hook("Echo") == nil {
+		t.Error("hook of Echo lost by reset")
+	}
+	if r.Hook("Chat") != nil {
+		t.Error("hook of Chat set")
+	}
+}
@@ -0,0 +1,94 @@
+package frog
+
+import (
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// optionKey identifies a cached option of a service, or of its method index.
+type optionKey struct {
+	method int // -1 for service options
+	ext    protoreflect.FullName
+}
+
+// optionValue is a cached option value, nil if the option is not set.
+type optionValue struct {
+	value interface{}
+}
+
+// MethodOption returns the value of the custom method option ext of md, and
+// whether the option is set. T is the Go type of the option, e.g. int32 or
+// string for scalar options; a pointer to T is dereferenced. It returns
+// false if the option has another type. Values are cached by the service.
+//
+// Example:
+//
+//	id, ok := frog.MethodOption[int32](md, E_MethodId)
+func MethodOption[T any](md *MethodDesc, ext protoreflect.ExtensionType) (T, bool) {
+	return optionAs[T](md.service.option(md.index, ext))
+}
+
+// ServiceOption is like MethodOption, for the custom service option ext of sd.
+func ServiceOption[T any](sd *ServiceDesc, ext protoreflect.ExtensionType) (T, bool) {
+	return optionAs[T](sd.option(-1, ext))
+}
+
+// option returns the cached value of the option ext of the service, or of
+// its method index, nil if it is not set.
+func (sd *ServiceDesc) option(index int, ext protoreflect.ExtensionType) interface{} {
+	key := optionKey{index, ext.TypeDescriptor().FullName()}
+	if v, ok := sd.options.Load(key); ok {
+		return v.(optionValue).value
+	}
+
+	var opts proto.Message
+	if index < 0 {
+		opts = sd.descriptor.Options()
+	} else {
+		opts = sd.descriptor.Methods().Get(index).Options()
+	}
+	var value interface{}
+	if opts = resolveOptions(opts); proto.HasExtension(opts, ext) {
+		value = proto.GetExtension(opts, ext)
+	}
+	sd.options.Store(key, optionValue{value})
+	return value
+}
+
+// resolveOptions parses the extensions of opts kept as unknown fields,
+// which were not linked in when opts was decoded.
+func resolveOptions(opts proto.Message) proto.Message {
+	if opts == nil || len(opts.ProtoReflect().GetUnknown()) == 0 {
+		return opts
+	}
+	b, err := proto.Marshal(opts)
+	if err != nil {
+		return opts
+	}
+	resolved := opts.ProtoReflect().New().Interface()
+	if err := (proto.UnmarshalOptions{Resolver: protoregistry.GlobalTypes}).Unmarshal(b, resolved); err != nil {
+		return opts
+	}
+	return resolved
+}
+
+func optionAs[T any](value interface{}) (T, bool) {
+	var zero T
+	if value == nil {
+		return zero, false
+	}
+	if v, ok := value.(T); ok {
+		return v, true
+	}
+	// options of legacy extensions are pointers to the value
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if v, ok := rv.Elem().Interface().(T); ok {
+			return v, true
+		}
+	}
+	return zero, false
+}
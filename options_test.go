@@ -0,0 +1,91 @@
+package frog
+
+import (
+	"testing"
+
+	"github.com/yplusplus/frog/options"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// optionsService is a service whose method Set has the frog.policy option,
+// Raw has it as an unknown field, and Unset has none.
+var optionsService = registerOptionsService()
+
+func registerOptionsService() *ServiceDesc {
+	policy := &options.CallPolicy{TimeoutMs: proto.Uint32(500), Idempotent: proto.Bool(true)}
+	set := new(descriptorpb.MethodOptions)
+	proto.SetExtension(set, options.E_Policy, policy)
+
+	// options decoded without the extension linked in keep it unknown
+	b, err := proto.Marshal(set)
+	if err != nil {
+		panic(err)
+	}
+	raw := new(descriptorpb.MethodOptions)
+	if err := (proto.UnmarshalOptions{Resolver: new(protoregistry.Types)}).Unmarshal(b, raw); err != nil {
+		panic(err)
+	}
+
+	serviceOpts := new(descriptorpb.ServiceOptions)
+	proto.SetExtension(serviceOpts, options.E_DefaultPolicy, &options.CallPolicy{MaxRetries: proto.Uint32(3)})
+
+	method := func(name string, opts *descriptorpb.MethodOptions) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(name),
+			InputType:  proto.String(".google.protobuf.StringValue"),
+			OutputType: proto.String(".google.protobuf.StringValue"),
+			Options:    opts,
+		}
+	}
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("frog/test_options.proto"),
+		Package:    proto.String("frog.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/wrappers.proto", "options/options.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name:    proto.String("Options"),
+			Options: serviceOpts,
+			Method:  []*descriptorpb.MethodDescriptorProto{method("Set", set), method("Raw", raw), method("Unset", nil)},
+		}},
+	}
+	file, err := protodesc.NewFile(fd, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	GenerateServiceDescFromFile(file)
+	return ServiceDescriptor("frog.test.Options")
+}
+
+func TestMethodOption(t *testing.T) {
+	for _, name := range []string{"Set", "Raw"} {
+		md := optionsService.MethodByName(name)
+		for i := 0; i < 2; i++ { // cached the second time
+			p, ok := MethodOption[*options.CallPolicy](md, options.E_Policy)
+			if !ok || p.GetTimeoutMs() != 500 || !p.GetIdempotent() {
+				t.Errorf("policy of %s = %v, %v, want timeout 500 and idempotent", name, p, ok)
+			}
+		}
+		if v, ok := MethodOption[string](md, options.E_Policy); ok || v != "" {
+			t.Errorf("policy of %s as string = %q, %v, want not ok", name, v, ok)
+		}
+	}
+
+	if p, ok := MethodOption[*options.CallPolicy](optionsService.MethodByName("Unset"), options.E_Policy); ok || p != nil {
+		t.Errorf("absent policy = %v, %v, want nil, false", p, ok)
+	}
+}
+
+func TestServiceOption(t *testing.T) {
+	if p, ok := ServiceOption[*options.CallPolicy](optionsService, options.E_DefaultPolicy); !ok || p.GetMaxRetries() != 3 {
+		t.Errorf("default policy = %v, %v, want max retries 3", p, ok)
+	}
+	if v, ok := ServiceOption[int32](optionsService, options.E_DefaultPolicy); ok || v != 0 {
+		t.Errorf("default policy as int32 = %v, %v, want not ok", v, ok)
+	}
+	if p, ok := ServiceOption[*options.CallPolicy](testService, options.E_DefaultPolicy); ok || p != nil {
+		t.Errorf("absent default policy = %v, %v, want nil, false", p, ok)
+	}
+}
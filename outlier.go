@@ -0,0 +1,380 @@
+package frog
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// OutlierConfig configures an OutlierPolicy. Zero values take the defaults.
+type OutlierConfig struct {
+	// Interval between two analyses of error rates and latencies, 10 seconds by default.
+	Interval time.Duration
+
+	// BaseEjectionTime is the first ejection time of a backend, 30 seconds by default.
+	// It doubles with every further ejection, up to MaxEjectionTime.
+	BaseEjectionTime time.Duration
+
+	// MaxEjectionTime caps the ejection time, 5 minutes by default.
+	MaxEjectionTime time.Duration
+
+	// MaxEjectionPercent caps the share of ejected backends, 10 by default.
+	// At least one backend can be ejected.
+	MaxEjectionPercent int
+
+	// ConsecutiveErrors ejects a backend immediately after so many failures
+	// in a row, 5 by default. Negative disables it.
+	ConsecutiveErrors int
+
+	// ErrorRate ejects a backend whose failure rate in an interval exceeds it.
+	// Zero disables it.
+	ErrorRate float64
+
+	// LatencyFactor ejects a backend whose mean latency in an interval exceeds
+	// LatencyFactor times the median of all backends. Zero disables it.
+	LatencyFactor float64
+
+	// MinRequests is the number of calls in an interval needed before the
+	// error rate and latency of a backend are judged, 10 by default.
+	MinRequests int
+
+	// IsFailure tells whether a call error counts against the backend.
+	// By default every non-nil error does.
+	IsFailure func(err error) bool
+
+	// OnEvent, if not nil, is called on every ejection and restoration.
+	OnEvent func(event OutlierEvent)
+}
+
+// OutlierEvent reports a backend being ejected or restored.
+type OutlierEvent struct {
+	Addr     string
+	Ejected  bool          // false means restored
+	Reason   string        // why it was ejected
+	Duration time.Duration // how long it is ejected for
+	Time     time.Time
+}
+
+// BackendStats is a snapshot of the outlier statistics of a backend.
+type BackendStats struct {
+	Addr           string
+	Ejected        bool
+	EjectedUntil   time.Time
+	EjectionCount  int // current ejection multiplier
+	TotalRequests  uint64
+	TotalFailures  uint64
+	IntervalCalls  int
+	IntervalErrors int
+	MeanLatency    time.Duration // of the current interval
+}
+
+// OutlierStats is a snapshot of the metrics of an OutlierPolicy.
+type OutlierStats struct {
+	Ejections    uint64
+	Restorations uint64
+	Backends     []BackendStats
+}
+
+type outlierBackend struct {
+	calls       int
+	failures    int
+	latency     time.Duration
+	consecutive int
+
+	totalCalls    uint64
+	totalFailures uint64
+
+	ejected      bool
+	ejectedUntil time.Time
+	ejectCount   int
+}
+
+// OutlierPolicy wraps a BalancePolicy, tracks the error rate and latency of
+// every backend and temporarily hides outliers from the wrapped policy.
+type OutlierPolicy struct {
+	inner  BalancePolicy
+	config OutlierConfig
+
+	mu           sync.Mutex // protect following fields
+	addrs        []string
+	backends     map[string]*outlierBackend
+	ejections    uint64
+	restorations uint64
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewOutlierPolicy(inner BalancePolicy, config OutlierConfig) *OutlierPolicy {
+	if config.Interval <= 0 {
+		config.Interval = 10 * time.Second
+	}
+	if config.BaseEjectionTime <= 0 {
+		config.BaseEjectionTime = 30 * time.Second
+	}
+	if config.MaxEjectionTime <= 0 {
+		config.MaxEjectionTime = 5 * time.Minute
+	}
+	if config.MaxEjectionPercent <= 0 {
+		config.MaxEjectionPercent = 10
+	}
+	if config.ConsecutiveErrors == 0 {
+		config.ConsecutiveErrors = 5
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(err error) bool { return err != nil }
+	}
+
+	p := &OutlierPolicy{
+		inner:    inner,
+		config:   config,
+		backends: make(map[string]*outlierBackend),
+		stop:     make(chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+func (p *OutlierPolicy) Update(addrs []string) {
+	p.mu.Lock()
+	backends := make(map[string]*outlierBackend, len(addrs))
+	for _, addr := range addrs {
+		if b, ok := p.backends[addr]; ok {
+			backends[addr] = b
+		} else {
+			backends[addr] = new(outlierBackend)
+		}
+	}
+	p.addrs = append([]string(nil), addrs...)
+	p.backends = backends
+	p.updateInner()
+	p.mu.Unlock()
+}
+
+func (p *OutlierPolicy) Pick(method *MethodDesc, ctx context.Context, request proto.Message) (string, func(error), error) {
+	addr, done, err := p.inner.Pick(method, ctx, request)
+	if err != nil {
+		return addr, done, err
+	}
+
+	start := time.Now()
+	return addr, func(err error) {
+		if done != nil {
+			done(err)
+		}
+		p.record(addr, err, time.Since(start))
+	}, nil
+}
+
+// Stats returns a snapshot of the outlier metrics.
+func (p *OutlierPolicy) Stats() OutlierStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := OutlierStats{
+		Ejections:    p.ejections,
+		Restorations: p.restorations,
+		Backends:     make([]BackendStats, 0, len(p.addrs)),
+	}
+	for _, addr := range p.addrs {
+		b := p.backends[addr]
+		s := BackendStats{
+			Addr:           addr,
+			Ejected:        b.ejected,
+			EjectedUntil:   b.ejectedUntil,
+			EjectionCount:  b.ejectCount,
+			TotalRequests:  b.totalCalls,
+			TotalFailures:  b.totalFailures,
+			IntervalCalls:  b.calls,
+			IntervalErrors: b.failures,
+		}
+		if b.calls > 0 {
+			s.MeanLatency = b.latency / time.Duration(b.calls)
+		}
+		stats.Backends = append(stats.Backends, s)
+	}
+	return stats
+}
+
+// Close stops the periodic analysis, and closes the inner policy if it
+// implements io.Closer.
+func (p *OutlierPolicy) Close() error {
+	var err error
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		if c, ok := p.inner.(io.Closer); ok {
+			err = c.Close()
+		}
+	})
+	return err
+}
+
+func (p *OutlierPolicy) record(addr string, err error, latency time.Duration) {
+	var events []OutlierEvent
+
+	p.mu.Lock()
+	b, ok := p.backends[addr]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+	b.calls++
+	b.totalCalls++
+	b.latency += latency
+	if p.config.IsFailure(err) {
+		b.failures++
+		b.totalFailures++
+		b.consecutive++
+		if p.config.ConsecutiveErrors > 0 && b.consecutive >= p.config.ConsecutiveErrors && !b.ejected {
+			if ev, ok := p.eject(addr, b, "consecutive errors", time.Now()); ok {
+				events = append(events, ev)
+				p.updateInner()
+			}
+		}
+	} else {
+		b.consecutive = 0
+	}
+	p.mu.Unlock()
+
+	p.notify(events)
+}
+
+func (p *OutlierPolicy) loop() {
+	t := time.NewTicker(p.config.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case now := <-t.C:
+			p.notify(p.sweep(now))
+		}
+	}
+}
+
+// sweep restores backends whose ejection expired, judges the last interval
+// and starts a new one.
+func (p *OutlierPolicy) sweep(now time.Time) []OutlierEvent {
+	var events []OutlierEvent
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+	restored := make(map[string]bool)
+	for _, addr := range p.addrs {
+		b := p.backends[addr]
+		if b.ejected && !now.Before(b.ejectedUntil) {
+			b.ejected = false
+			b.consecutive = 0
+			p.restorations++
+			events = append(events, OutlierEvent{Addr: addr, Time: now})
+			restored[addr] = true
+			changed = true
+		}
+	}
+
+	median := p.medianLatency()
+	for _, addr := range p.addrs {
+		b := p.backends[addr]
+		outlier := ""
+		if !b.ejected && b.calls >= p.config.MinRequests {
+			if p.config.ErrorRate > 0 && float64(b.failures)/float64(b.calls) > p.config.ErrorRate {
+				outlier = "error rate"
+			} else if p.config.LatencyFactor > 0 && median > 0 &&
+				float64(b.latency/time.Duration(b.calls)) > p.config.LatencyFactor*float64(median) {
+				outlier = "latency"
+			}
+		}
+
+		if outlier != "" {
+			if ev, ok := p.eject(addr, b, outlier, now); ok {
+				events = append(events, ev)
+				changed = true
+			}
+		} else if !b.ejected && !restored[addr] && b.ejectCount > 0 {
+			// healthy for an interval, forgive one ejection
+			b.ejectCount--
+		}
+
+		b.calls, b.failures, b.latency = 0, 0, 0
+	}
+
+	if changed {
+		p.updateInner()
+	}
+	return events
+}
+
+// medianLatency returns the median of mean latencies of backends with enough calls.
+func (p *OutlierPolicy) medianLatency() time.Duration {
+	var means []time.Duration
+	for _, addr := range p.addrs {
+		b := p.backends[addr]
+		if !b.ejected && b.calls >= p.config.MinRequests {
+			means = append(means, b.latency/time.Duration(b.calls))
+		}
+	}
+	if len(means) == 0 {
+		return 0
+	}
+	sort.Slice(means, func(i, j int) bool { return means[i] < means[j] })
+	return means[len(means)/2]
+}
+
+// eject ejects b if the maximum ejection percentage allows it. p.mu must be held.
+func (p *OutlierPolicy) eject(addr string, b *outlierBackend, reason string, now time.Time) (OutlierEvent, bool) {
+	ejected := 0
+	for _, other := range p.backends {
+		if other.ejected {
+			ejected++
+		}
+	}
+	max := len(p.addrs) * p.config.MaxEjectionPercent / 100
+	if max < 1 {
+		max = 1
+	}
+	if ejected >= max || ejected >= len(p.addrs)-1 {
+		return OutlierEvent{}, false
+	}
+
+	b.ejectCount++
+	d := p.config.BaseEjectionTime
+	for i := 1; i < b.ejectCount && d < p.config.MaxEjectionTime; i++ {
+		d *= 2
+	}
+	if d > p.config.MaxEjectionTime {
+		d = p.config.MaxEjectionTime
+	}
+	b.ejected = true
+	b.ejectedUntil = now.Add(d)
+	p.ejections++
+	return OutlierEvent{Addr: addr, Ejected: true, Reason: reason, Duration: d, Time: now}, true
+}
+
+// updateInner passes the backends not ejected to the wrapped policy. p.mu must be held.
+func (p *OutlierPolicy) updateInner() {
+	addrs := make([]string, 0, len(p.addrs))
+	for _, addr := range p.addrs {
+		if !p.backends[addr].ejected {
+			addrs = append(addrs, addr)
+		}
+	}
+	p.inner.Update(addrs)
+}
+
+func (p *OutlierPolicy) notify(events []OutlierEvent) {
+	if p.config.OnEvent == nil {
+		return
+	}
+	for _, ev := range events {
+		p.config.OnEvent(ev)
+	}
+}
@@ -0,0 +1,192 @@
+package frog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// failingChannel completes calls at once, and fails them if fail is set.
+type failingChannel struct {
+	countingChannel
+	fail bool
+}
+
+func (c *failingChannel) Go(method *MethodDesc, ctx context.Context, request proto.Message, response proto.Message) RpcCall {
+	c.mu.Lock()
+	c.counts[c.addr]++
+	c.mu.Unlock()
+	call := NewDefaultCall(request, response)
+	if c.fail {
+		call.Close(Errorf(Unavailable, "%s is down", c.addr))
+	} else {
+		call.Close(nil)
+	}
+	return call
+}
+
+// newOutlierTestPolicy returns a policy whose periodic analysis never runs,
+// so tests run it with sweep.
+func newOutlierTestPolicy(config OutlierConfig) (*OutlierPolicy, *[]OutlierEvent) {
+	var mu sync.Mutex
+	var events []OutlierEvent
+	config.Interval = time.Hour
+	config.OnEvent = func(ev OutlierEvent) {
+		mu.Lock()
+		events = append(events, ev)
+		mu.Unlock()
+	}
+	return NewOutlierPolicy(NewRoundRobinPolicy(), config), &events
+}
+
+func backendStats(p *OutlierPolicy, addr string) BackendStats {
+	for _, s := range p.Stats().Backends {
+		if s.Addr == addr {
+			return s
+		}
+	}
+	return BackendStats{}
+}
+
+func TestOutlierConsecutiveErrors(t *testing.T) {
+	p, events := newOutlierTestPolicy(OutlierConfig{
+		BaseEjectionTime:   time.Minute,
+		MaxEjectionPercent: 50,
+	})
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	dial := func(addr string) (RpcChannel, error) {
+		return &failingChannel{countingChannel{addr, &mu, counts}, addr == "bad"}, nil
+	}
+	bc, err := NewBalancingChannel(dial, p, "bad", "good1", "good2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Close()
+
+	method := testService.Method(testEcho)
+	for i := 0; i < 30; i++ {
+		<-bc.Go(method, context.Background(), nil, nil).Done()
+	}
+	mu.Lock()
+	if counts["bad"] != 5 {
+		t.Errorf("bad backend got %d calls, want 5 before its ejection", counts["bad"])
+	}
+	mu.Unlock()
+
+	stats := p.Stats()
+	if stats.Ejections != 1 {
+		t.Fatalf("ejections = %d, want 1", stats.Ejections)
+	}
+	bad := backendStats(p, "bad")
+	if !bad.Ejected || bad.TotalFailures != 5 {
+		t.Errorf("stats of bad backend = %+v", bad)
+	}
+	if len(*events) != 1 || !(*events)[0].Ejected || (*events)[0].Reason != "consecutive errors" || (*events)[0].Duration != time.Minute {
+		t.Errorf("events = %+v", *events)
+	}
+
+	// the backend comes back once its ejection expires
+	p.notify(p.sweep(time.Now()))
+	if !backendStats(p, "bad").Ejected {
+		t.Error("backend restored before its ejection expired")
+	}
+	p.notify(p.sweep(bad.EjectedUntil))
+	if backendStats(p, "bad").Ejected || p.Stats().Restorations != 1 {
+		t.Errorf("backend not restored: %+v", p.Stats())
+	}
+
+	// a second ejection lasts twice as long
+	for i := 0; i < 15; i++ {
+		<-bc.Go(method, context.Background(), nil, nil).Done()
+	}
+	if ev := (*events)[len(*events)-1]; !ev.Ejected || ev.Duration != 2*time.Minute {
+		t.Errorf("second ejection = %+v, want 2m", ev)
+	}
+}
+
+func TestOutlierErrorRateAndLatency(t *testing.T) {
+	p, events := newOutlierTestPolicy(OutlierConfig{
+		ConsecutiveErrors:  -1,
+		ErrorRate:          0.5,
+		LatencyFactor:      3,
+		MaxEjectionPercent: 50,
+		IsFailure:          func(err error) bool { return ErrorCode(err) == Unavailable },
+	})
+	defer p.Close()
+	p.Update([]string{"a", "b", "c", "d", "e"})
+
+	for i := 0; i < 10; i++ {
+		p.record("a", Errorf(Unavailable, "down"), time.Millisecond)
+		p.record("b", errors.New("not a failure"), time.Millisecond)
+		p.record("c", nil, time.Millisecond)
+		p.record("d", nil, 10*time.Millisecond)
+		p.record("e", nil, time.Millisecond)
+	}
+	p.notify(p.sweep(time.Now()))
+
+	ejected := make(map[string]string)
+	for _, ev := range *events {
+		ejected[ev.Addr] = ev.Reason
+	}
+	if len(ejected) != 2 || ejected["a"] != "error rate" || ejected["d"] != "latency" {
+		t.Errorf("ejected backends = %v, want a for error rate and d for latency", ejected)
+	}
+	if s := backendStats(p, "c"); s.IntervalCalls != 0 || s.TotalRequests != 10 {
+		t.Errorf("stats of c after the interval = %+v", s)
+	}
+}
+
+func TestOutlierMaxEjectionPercent(t *testing.T) {
+	p, _ := newOutlierTestPolicy(OutlierConfig{ConsecutiveErrors: 1, MaxEjectionPercent: 10})
+	defer p.Close()
+	p.Update([]string{"a", "b", "c"})
+
+	// one backend can always be ejected, but not more than 10 percent
+	for _, addr := range []string{"a", "b", "c"} {
+		p.record(addr, errors.New("down"), time.Millisecond)
+	}
+	if stats := p.Stats(); stats.Ejections != 1 {
+		t.Errorf("ejections = %d, want 1", stats.Ejections)
+	}
+	for i := 0; i < 10; i++ {
+		addr, done, err := p.Pick(testService.Method(testEcho), context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr == "a" {
+			t.Fatal("ejected backend picked")
+		}
+		done(nil)
+	}
+}
+
+// closingPolicy is a round robin policy recording whether it is closed.
+type closingPolicy struct {
+	*RoundRobinPolicy
+	closed int
+}
+
+func (p *closingPolicy) Close() error {
+	p.closed++
+	return nil
+}
+
+func TestOutlierClosesInnerPolicy(t *testing.T) {
+	dial, _ := newCountingDialer()
+	inner := &closingPolicy{RoundRobinPolicy: NewRoundRobinPolicy()}
+	p := NewOutlierPolicy(inner, OutlierConfig{})
+	bc, err := NewBalancingChannel(dial, p, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc.Close()
+	p.Close()
+	if inner.closed != 1 {
+		t.Errorf("inner policy closed %d times, want once", inner.closed)
+	}
+}
@@ -17,7 +17,8 @@ import (
 // Paths for packages used by code generated in this file,
 // relative to the import_prefix of the generator.Generator.
 const (
-	frogPkgPath = "github.com/yplusplus/frog"
+	frogPkgPath    = "github.com/yplusplus/frog"
+	protoV2PkgPath = "google.golang.org/protobuf/proto"
 )
 
 func init() {
@@ -39,13 +40,15 @@ func (g *frogGen) Name() string {
 // They may vary from the final path component of the import path
 // if the name is used by other packages.
 var (
-	frogPkg string
+	frogPkg    string
+	protoV2Pkg string
 )
 
 // Init initializes the plugin.
 func (g *frogGen) Init(gen *generator.Generator) {
 	g.gen = gen
 	frogPkg = generator.RegisterUniquePackageName("frog", nil)
+	protoV2Pkg = generator.RegisterUniquePackageName("protoV2", nil)
 }
 
 // Given a type name defined in a .proto, return its object.
@@ -93,7 +96,11 @@ func (g *frogGen) Generate(file *generator.FileDescriptor) {
 
 	for _, service := range file.FileDescriptorProto.Service {
 		servDescName := frog.GenerateServiceDescName(service.GetName())
-		g.P(servDescName, " = frog.ServiceDescriptor(\""+servDescName+"\")")
+		fullServName := service.GetName()
+		if pkg := file.GetPackage(); pkg != "" {
+			fullServName = pkg + "." + fullServName
+		}
+		g.P(servDescName, " = frog.ServiceDescriptor(", strconv.Quote(fullServName), ")")
 	}
 
 	g.P("}")
@@ -108,16 +115,81 @@ func (g *frogGen) GenerateImports(file *generator.FileDescriptor) {
 	g.P("import (")
 	g.P("\"context\"")
 	g.P(frogPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, frogPkgPath)))
+	g.P(protoV2Pkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, protoV2PkgPath)))
 	g.P(")")
 	g.P()
 }
 
 func unexport(s string) string { return strings.ToLower(s[:1]) + s[1:] }
 
+// messageV2 returns the expression converting the generated message x to
+// the APIv2 message taken by the frog runtime.
+func messageV2(x string) string { return "proto.MessageV2(" + x + ")" }
+
+func isStreaming(method *desc.MethodDescriptorProto) bool {
+	return method.GetServerStreaming() || method.GetClientStreaming()
+}
+
+// deprecationComment is the line marking deprecated identifiers.
+const deprecationComment = " Deprecated: Do not use."
+
+// comments returns the lines of the leading and trailing comments of the
+// element of file at path, followed by a deprecation notice if deprecated.
+// Paragraphs are separated by empty lines.
+func comments(file *generator.FileDescriptor, path string, deprecated bool) []string {
+	var lines []string
+	add := func(paragraph ...string) {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, paragraph...)
+	}
+	for _, loc := range file.GetSourceCodeInfo().GetLocation() {
+		if locationPath(loc.Path) != path {
+			continue
+		}
+		for _, c := range []string{loc.GetLeadingComments(), loc.GetTrailingComments()} {
+			if c != "" {
+				add(strings.Split(strings.TrimSuffix(c, "\n"), "\n")...)
+			}
+		}
+		break
+	}
+	if deprecated {
+		add(deprecationComment)
+	}
+	return lines
+}
+
+func locationPath(path []int32) string {
+	p := make([]string, len(path))
+	for i, n := range path {
+		p[i] = strconv.Itoa(int(n))
+	}
+	return strings.Join(p, ",")
+}
+
+// asyncDoc returns the header of the doc comment of the AsyncXxx variant of a method.
+func asyncDoc(methName string) string {
+	return "Async" + methName + " is the asynchronous version of " + methName + "."
+}
+
+// printDoc prints a doc comment made of the header line, if any, and lines.
+func (g *frogGen) printDoc(header string, lines []string) {
+	if header != "" {
+		g.P("// ", header)
+		if len(lines) > 0 {
+			g.P("//")
+		}
+	}
+	for _, line := range lines {
+		g.P("//", line)
+	}
+}
+
 // generateService generates all the code for the named service.
 func (g *frogGen) generateService(file *generator.FileDescriptor, service *desc.ServiceDescriptorProto, index int) {
 	path := fmt.Sprintf("6,%d", index) // 6 means service.
-	_ = path
 
 	origServName := service.GetName()
 	fullServName := origServName
@@ -126,10 +198,53 @@ func (g *frogGen) generateService(file *generator.FileDescriptor, service *desc.
 	}
 	servName := generator.CamelCase(origServName)
 
+	servDeprecated := service.GetOptions().GetDeprecated()
+	servDoc := comments(file, path, servDeprecated)
+	methDocs := make([][]string, len(service.Method))
+	for i, method := range service.Method {
+		methPath := fmt.Sprintf("%s,2,%d", path, i) // 2 means method in a service.
+		methDocs[i] = comments(file, methPath, method.GetOptions().GetDeprecated())
+	}
+
 	g.P()
 	g.P("// Stub for ", servName, " service")
 	g.P()
 
+	// Method names and descriptors.
+	g.P("// Full names of the methods of ", servName, " service, as returned by MethodDesc.FullName.")
+	g.P("const (")
+	for _, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		g.P(frog.GenerateFullMethodNameConst(servName, methName), " = ", strconv.Quote("/"+fullServName+"/"+method.GetName()))
+	}
+	g.P(")")
+	g.P()
+	for index, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		methDescName := frog.GenerateMethodDescName(servName, methName)
+		g.P("// ", methDescName, " returns the descriptor of the ", methName, " method of ", servName, " service.")
+		g.P("func ", methDescName, "() *", frogPkg, ".MethodDesc {")
+		g.P("return ", frog.GenerateServiceDescName(servName), ".Method(", index, ")")
+		g.P("}")
+		g.P()
+	}
+
+	// Client interface.
+	g.printDoc(servName+"Client is the client API for "+servName+" service.", servDoc)
+	g.P("type ", servName, "Client interface {")
+	for i, method := range service.Method {
+		g.printDoc("", methDocs[i])
+		if isStreaming(method) {
+			g.P(g.generateStubStreamSignature(servName, method))
+			continue
+		}
+		g.P(g.generateStubSignature(servName, method))
+		g.printDoc(asyncDoc(generator.CamelCase(method.GetName())), methDocs[i])
+		g.P(g.generateStubAsyncSignature(servName, method))
+	}
+	g.P("}")
+	g.P()
+
 	// Stub structure.
 	g.P("type ", unexport(servName), "Stub struct {")
 	g.P("channel ", frogPkg, ".RpcChannel")
@@ -137,49 +252,343 @@ func (g *frogGen) generateService(file *generator.FileDescriptor, service *desc.
 	g.P()
 
 	// NewStub factory.
-	g.P("func New", servName, "Stub (channel ", frogPkg, ".RpcChannel) *", unexport(servName), "Stub {")
+	g.printDoc("New"+servName+"Stub returns the "+servName+"Client calling methods on channel.", servDoc)
+	g.P("func New", servName, "Stub (channel ", frogPkg, ".RpcChannel) ", servName, "Client {")
 	g.P("return &", unexport(servName), "Stub{channel}")
 	g.P("}")
 	g.P()
 
 	// Stub method implementations.
 	for index, method := range service.Method {
-		if !method.GetServerStreaming() && !method.GetClientStreaming() {
-		} else {
-			g.gen.Fail("not support streaming method")
-		}
-		g.generateStubMethod(servName, fullServName, method, index)
+		deprecated := servDeprecated || method.GetOptions().GetDeprecated()
+		g.generateStubMethod(servName, method, methDocs[index], deprecated)
 	}
 
 	// Stub call method and go method
 	g.P("func (stub *", unexport(servName), "Stub) Call(method *"+frogPkg+".MethodDesc, ctx context.Context, in proto.Message, out proto.Message) error {")
-	g.P("call := stub.channel.Go(method, ctx, in, out)")
+	g.P("call := stub.channel.Go(method, ctx, ", messageV2("in"), ", ", messageV2("out"), ")")
 	g.P("<-call.Done()")
 	g.P("return call.Error()")
 	g.P("}")
 	g.P()
 
 	g.P("func (stub *", unexport(servName), "Stub) Go(method *"+frogPkg+".MethodDesc, ctx context.Context, in proto.Message, out proto.Message)  ", frogPkg, ".RpcCall {")
-	g.P("return stub.channel.Go(method, ctx, in, out)")
+	g.P("return stub.channel.Go(method, ctx, ", messageV2("in"), ", ", messageV2("out"), ")")
 	g.P("}")
 	g.P()
 
 	// Server interface.
+	g.printDoc(servName+" is the server API for "+servName+" service.", servDoc)
 	g.P("type ", servName, " interface {")
 	for i, method := range service.Method {
-		g.gen.PrintComments(fmt.Sprintf("%s,2,%d", path, i)) // 2 means method in a service.
+		g.printDoc("", methDocs[i])
 		g.P(g.generateServerSignature(servName, method))
 	}
 	g.P("}")
 	g.P()
 
+	// Unimplemented base structure.
+	g.P("// Unimplemented", servName, " should be embedded by implementations of ", servName, ",")
+	g.P("// so they keep compiling and registering when methods are added to the service.")
+	g.P("type Unimplemented", servName, " struct{}")
+	g.P()
+	for _, method := range service.Method {
+		g.P("func (Unimplemented", servName, ") ", g.generateServerMethodSignature(servName, method), " {")
+		g.P("return ", frogPkg, ".Errorf(", frogPkg, ".Unimplemented, \"method ", generator.CamelCase(method.GetName()), " not implemented\")")
+		g.P("}")
+		g.P()
+	}
+
 	// generate registerXxxService
 	// func RegisterEchoService(service EchoService, register frog.MethodsRegister) error {
-	//     return frog.RegisterService(EchoService_ServiceDesc, service)
+	//     return frog.RegisterServiceHandlers(EchoService_ServiceDesc, service, register, _EchoService_Handlers)
 	// }
 	g.P(fmt.Sprintf("func Register%s(service %s, register %s.MethodsRegister) error {", servName, servName, frogPkg))
-	g.P(fmt.Sprintf("return %s.RegisterService(%s, service, register)", frogPkg, frog.GenerateServiceDescName(servName)))
+	g.P(fmt.Sprintf("return %s.RegisterServiceHandlers(%s, service, register, _%s_Handlers)", frogPkg, frog.GenerateServiceDescName(servName), servName))
+	g.P("}")
+	g.P()
+
+	g.generateHandlers(servName, service)
+	g.generateAsyncService(servName, service, servDoc, methDocs)
+
+	if g.gen.Param["frog_mock"] == "true" {
+		g.P()
+		g.generateMock(servName, service)
+	}
+}
+
+// generateHandlers generates the handlers serving the service without reflection.
+func (g *frogGen) generateHandlers(servName string, service *desc.ServiceDescriptorProto) {
+	g.P("var _", servName, "_Handlers = &", frogPkg, ".ServiceHandlers{")
+	g.P("Dispatch: _", servName, "_Dispatch,")
+	g.P("NewRequest: _", servName, "_NewRequest,")
+	g.P("NewResponse: _", servName, "_NewResponse,")
+	g.generateStreams(service, servName)
+	g.P("}")
+	g.P()
+
+	// Dispatch calls the unary methods.
+	g.P("func _", servName, "_Dispatch(service interface{}, index int, ctx context.Context, in ", protoV2Pkg, ".Message, out ", protoV2Pkg, ".Message) error {")
+	g.P("switch index {")
+	for i, method := range service.Method {
+		if isStreaming(method) {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		inType := g.typeName(method.GetInputType())
+		outType := g.typeName(method.GetOutputType())
+		g.P("case ", i, ":")
+		g.P("return service.(", servName, ").", methName, "(ctx, proto.MessageV1(in).(*", inType, "), proto.MessageV1(out).(*", outType, "))")
+	}
+	g.P("}")
+	g.P("return ", frogPkg, ".Errorf(", frogPkg, ".Unimplemented, \"", servName, " has no unary method %d\", index)")
+	g.P("}")
+	g.P()
+
+	// Message factories.
+	g.P("func _", servName, "_NewRequest(index int) ", protoV2Pkg, ".Message {")
+	g.P("switch index {")
+	for i, method := range service.Method {
+		g.P("case ", i, ":")
+		g.P("return ", messageV2("new("+g.typeName(method.GetInputType())+")"))
+	}
+	g.P("}")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+
+	g.P("func _", servName, "_NewResponse(index int) ", protoV2Pkg, ".Message {")
+	g.P("switch index {")
+	for i, method := range service.Method {
+		g.P("case ", i, ":")
+		g.P("return ", messageV2("new("+g.typeName(method.GetOutputType())+")"))
+	}
+	g.P("}")
+	g.P("return nil")
+	g.P("}")
+
+	for _, method := range service.Method {
+		if isStreaming(method) {
+			g.P()
+			g.generateServerStream(servName, method)
+		}
+	}
+}
+
+// generateAsyncService generates the server API of the service served
+// asynchronously, whose unary methods take a frog.RpcController, and its
+// handlers.
+func (g *frogGen) generateAsyncService(servName string, service *desc.ServiceDescriptorProto, servDoc []string, methDocs [][]string) {
+	asyncName := servName + "Async"
+
+	g.P()
+	g.P("// ", asyncName, " is the server API for ", servName, " service served asynchronously:")
+	g.P("// unary methods may return at once and complete the call later with ctrl.Done.")
+	if len(servDoc) > 0 {
+		g.P("//")
+		g.printDoc("", servDoc)
+	}
+	g.P("type ", asyncName, " interface {")
+	for i, method := range service.Method {
+		g.printDoc("", methDocs[i])
+		g.P(g.generateAsyncServerSignature(servName, method))
+	}
+	g.P("}")
+	g.P()
+
+	// Unimplemented base structure.
+	g.P("// Unimplemented", asyncName, " should be embedded by implementations of ", asyncName, ",")
+	g.P("// so they keep compiling and registering when methods are added to the service.")
+	g.P("type Unimplemented", asyncName, " struct{}")
+	g.P()
+	for _, method := range service.Method {
+		unimplemented := fmt.Sprintf("%s.Errorf(%s.Unimplemented, \"method %s not implemented\")", frogPkg, frogPkg, generator.CamelCase(method.GetName()))
+		g.P("func (Unimplemented", asyncName, ") ", g.generateAsyncServerMethodSignature(servName, method), " {")
+		if isStreaming(method) {
+			g.P("return ", unimplemented)
+		} else {
+			g.P("ctrl.Done(", unimplemented, ")")
+		}
+		g.P("}")
+		g.P()
+	}
+
+	g.P(fmt.Sprintf("func Register%s(service %s, register %s.MethodsRegister) error {", asyncName, asyncName, frogPkg))
+	g.P(fmt.Sprintf("return %s.RegisterServiceHandlers(%s, service, register, _%s_Handlers)", frogPkg, frog.GenerateServiceDescName(servName), asyncName))
+	g.P("}")
+	g.P()
+
+	g.P("var _", asyncName, "_Handlers = &", frogPkg, ".ServiceHandlers{")
+	g.P("AsyncDispatch: _", asyncName, "_Dispatch,")
+	g.P("NewRequest: _", servName, "_NewRequest,")
+	g.P("NewResponse: _", servName, "_NewResponse,")
+	g.generateStreams(service, asyncName)
+	g.P("}")
+	g.P()
+
+	// Dispatch calls the unary methods.
+	g.P("func _", asyncName, "_Dispatch(service interface{}, index int, ctrl ", frogPkg, ".RpcController, in ", protoV2Pkg, ".Message, out ", protoV2Pkg, ".Message) {")
+	g.P("switch index {")
+	for i, method := range service.Method {
+		if isStreaming(method) {
+			continue
+		}
+		methName := generator.CamelCase(method.GetName())
+		inType := g.typeName(method.GetInputType())
+		outType := g.typeName(method.GetOutputType())
+		g.P("case ", i, ":")
+		g.P("service.(", asyncName, ").", methName, "(ctrl, proto.MessageV1(in).(*", inType, "), proto.MessageV1(out).(*", outType, "))")
+		g.P("return")
+	}
+	g.P("}")
+	g.P("ctrl.Done(", frogPkg, ".Errorf(", frogPkg, ".Unimplemented, \"", servName, " has no unary method %d\", index))")
 	g.P("}")
+
+	for _, method := range service.Method {
+		if isStreaming(method) {
+			g.P()
+			g.generateStreamHandler(servName, asyncName, method)
+		}
+	}
+}
+
+// generateStreams generates the Streams field of the handlers of service,
+// whose stream handlers are named after prefix.
+func (g *frogGen) generateStreams(service *desc.ServiceDescriptorProto, prefix string) {
+	hasStreams := false
+	for _, method := range service.Method {
+		if isStreaming(method) {
+			hasStreams = true
+		}
+	}
+	if !hasStreams {
+		return
+	}
+	g.P("Streams: []", frogPkg, ".StreamHandler{")
+	for _, method := range service.Method {
+		if isStreaming(method) {
+			g.P("_", prefix, "_", generator.CamelCase(method.GetName()), "_Handler,")
+		} else {
+			g.P("nil,")
+		}
+	}
+	g.P("},")
+}
+
+// generateMock generates mock implementations of the server and client interfaces.
+func (g *frogGen) generateMock(servName string, service *desc.ServiceDescriptorProto) {
+	unimplemented := func(mock, methName string) string {
+		return fmt.Sprintf("%s.Errorf(%s.Unimplemented, \"%s.%s has no hook\")", frogPkg, frogPkg, mock, methName)
+	}
+
+	// Server mock.
+	mock := "Mock" + servName
+	g.P("// ", mock, " is a mock implementation of ", servName, ".")
+	g.P("// Methods without a hook fail with Unimplemented.")
+	g.P("type ", mock, " struct {")
+	g.P(frogPkg, ".MockRecorder")
+	g.P("}")
+	g.P()
+	g.P("var _ ", servName, " = (*", mock, ")(nil)")
+	for _, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		inType := g.typeName(method.GetInputType())
+		outType := g.typeName(method.GetOutputType())
+		streamType := servName + "_" + methName + "Server"
+
+		var hookType, call, in, out string
+		switch {
+		case method.GetClientStreaming():
+			hookType = "func(context.Context, " + streamType + ") error"
+			call, in, out = "fn(ctx, stream)", "nil", "nil"
+		case method.GetServerStreaming():
+			hookType = "func(context.Context, *" + inType + ", " + streamType + ") error"
+			call, in, out = "fn(ctx, in, stream)", messageV2("in"), "nil"
+		default:
+			hookType = "func(context.Context, *" + inType + ", *" + outType + ") error"
+			call, in, out = "fn(ctx, in, out)", messageV2("in"), messageV2("out")
+		}
+
+		g.P()
+		g.P("// On", methName, " sets the hook serving ", methName, ".")
+		g.P("func (m *", mock, ") On", methName, "(fn ", hookType, ") {")
+		g.P("m.SetHook(\"", methName, "\", fn)")
+		g.P("}")
+		g.P()
+		g.P("func (m *", mock, ") ", g.generateServerMethodSignature(servName, method), " {")
+		g.P("err := ", unimplemented(mock, methName))
+		g.P("if fn, ok := m.Hook(\"", methName, "\").(", hookType, "); ok {")
+		g.P("err = ", call)
+		g.P("}")
+		g.P("m.Record(\"", methName, "\", ", in, ", ", out, ", err)")
+		g.P("return err")
+		g.P("}")
+	}
+	g.P()
+
+	// Client mock.
+	mock = "Mock" + servName + "Client"
+	g.P("// ", mock, " is a mock implementation of ", servName, "Client.")
+	g.P("// Methods without a hook fail with Unimplemented, and AsyncXxx methods")
+	g.P("// share the hooks of their sync methods.")
+	g.P("type ", mock, " struct {")
+	g.P(frogPkg, ".MockRecorder")
+	g.P("}")
+	g.P()
+	g.P("var _ ", servName, "Client = (*", mock, ")(nil)")
+	for _, method := range service.Method {
+		methName := generator.CamelCase(method.GetName())
+		inType := g.typeName(method.GetInputType())
+		outType := g.typeName(method.GetOutputType())
+		streamType := servName + "_" + methName + "Client"
+
+		g.P()
+		if isStreaming(method) {
+			hookType := "func(context.Context, *" + inType + ") (" + streamType + ", error)"
+			call, in := "fn(ctx, in)", messageV2("in")
+			if method.GetClientStreaming() {
+				hookType = "func(context.Context) (" + streamType + ", error)"
+				call, in = "fn(ctx)", "nil"
+			}
+			g.P("// On", methName, " sets the hook serving ", methName, ".")
+			g.P("func (m *", mock, ") On", methName, "(fn ", hookType, ") {")
+			g.P("m.SetHook(\"", methName, "\", fn)")
+			g.P("}")
+			g.P()
+			g.P("func (m *", mock, ") ", g.generateStubStreamSignature(servName, method), " {")
+			g.P("if fn, ok := m.Hook(\"", methName, "\").(", hookType, "); ok {")
+			g.P("stream, err := ", call)
+			g.P("m.Record(\"", methName, "\", ", in, ", nil, err)")
+			g.P("return stream, err")
+			g.P("}")
+			g.P("err := ", unimplemented(mock, methName))
+			g.P("m.Record(\"", methName, "\", ", in, ", nil, err)")
+			g.P("return nil, err")
+			g.P("}")
+			continue
+		}
+
+		hookType := "func(context.Context, *" + inType + ", *" + outType + ") error"
+		g.P("// On", methName, " sets the hook serving ", methName, " and Async", methName, ".")
+		g.P("func (m *", mock, ") On", methName, "(fn ", hookType, ") {")
+		g.P("m.SetHook(\"", methName, "\", fn)")
+		g.P("}")
+		g.P()
+		g.P("func (m *", mock, ") ", g.generateStubSignature(servName, method), " {")
+		g.P("err := ", unimplemented(mock, methName))
+		g.P("if fn, ok := m.Hook(\"", methName, "\").(", hookType, "); ok {")
+		g.P("err = fn(ctx, in, out)")
+		g.P("}")
+		g.P("m.Record(\"", methName, "\", ", messageV2("in"), ", ", messageV2("out"), ", err)")
+		g.P("return err")
+		g.P("}")
+		g.P()
+		g.P("func (m *", mock, ") ", g.generateStubAsyncSignature(servName, method), " {")
+		g.P("call := ", frogPkg, ".NewDefaultCall(", messageV2("in"), ", ", messageV2("out"), ")")
+		g.P("call.Close(m.", methName, "(ctx, in, out))")
+		g.P("return ", frogPkg, ".NewTypedCall(call, out)")
+		g.P("}")
+	}
 }
 
 // generateStubSignature returns the stub signature for a method.
@@ -196,34 +605,230 @@ func (g *frogGen) generateStubAsyncSignature(servName string, method *desc.Metho
 	methName := generator.CamelCase(origMethName)
 	inType := g.typeName(method.GetInputType())
 	outType := g.typeName(method.GetOutputType())
-	return fmt.Sprintf("Async%s(ctx context.Context, in *%s, out *%s) %s.RpcCall", methName, inType, outType, frogPkg)
+	return fmt.Sprintf("Async%s(ctx context.Context, in *%s, out *%s) *%s.TypedCall[*%s]", methName, inType, outType, frogPkg, outType)
 }
 
-func (g *frogGen) generateStubMethod(servName, fullServName string, method *desc.MethodDescriptorProto, index int) {
-	if method.GetServerStreaming() || method.GetClientStreaming() {
-		g.gen.Fail("not support streaming method")
+// generateStubMethod generates the stub methods of a method, documented by
+// doc. Stubs of deprecated methods notify the frog deprecation handler.
+func (g *frogGen) generateStubMethod(servName string, method *desc.MethodDescriptorProto, doc []string, deprecated bool) {
+	if isStreaming(method) {
+		g.generateStubStream(servName, method, doc, deprecated)
+		return
 	}
 
-	servDescName := frog.GenerateServiceDescName(servName)
+	methDesc := frog.GenerateMethodDescName(servName, generator.CamelCase(method.GetName())) + "()"
 	// sync method
+	g.printDoc("", doc)
 	g.P("func (stub *", unexport(servName), "Stub) ", g.generateStubSignature(servName, method), "{")
-	g.P("err := stub.Call("+servDescName+".Method(", index, "), ctx, in, out)")
+	g.generateNotifyDeprecated(methDesc, deprecated)
+	g.P("err := stub.Call(", methDesc, ", ctx, in, out)")
 	g.P("return err")
 	g.P("}")
 	g.P()
 
 	// async method
+	g.printDoc(asyncDoc(generator.CamelCase(method.GetName())), doc)
 	g.P("func (stub *", unexport(servName), "Stub) ", g.generateStubAsyncSignature(servName, method), "{")
-	g.P("return stub.Go("+servDescName+".Method(", index, "), ctx, in, out)")
+	g.generateNotifyDeprecated(methDesc, deprecated)
+	g.P("return ", frogPkg, ".NewTypedCall(stub.Go(", methDesc, ", ctx, in, out), out)")
 	g.P("}")
 	g.P()
 }
 
+// generateNotifyDeprecated generates the notification of a call to a deprecated method.
+func (g *frogGen) generateNotifyDeprecated(methDesc string, deprecated bool) {
+	if deprecated {
+		g.P(frogPkg, ".NotifyDeprecated(", methDesc, ")")
+	}
+}
+
+// generateStubStream generates the stub method of a streaming method,
+// and its typed client stream.
+func (g *frogGen) generateStubStream(servName string, method *desc.MethodDescriptorProto, doc []string, deprecated bool) {
+	methName := generator.CamelCase(method.GetName())
+	inType := g.typeName(method.GetInputType())
+	outType := g.typeName(method.GetOutputType())
+	methDesc := frog.GenerateMethodDescName(servName, methName) + "()"
+	streamType := unexport(servName) + methName + "Client"
+
+	g.printDoc("", doc)
+	g.P("func (stub *", unexport(servName), "Stub) ", g.generateStubStreamSignature(servName, method), "{")
+	g.generateNotifyDeprecated(methDesc, deprecated)
+	g.P("stream, err := ", frogPkg, ".NewStream(stub.channel, ", methDesc, ", ctx)")
+	g.P("if err != nil { return nil, err }")
+	g.P("x := &", streamType, "{stream}")
+	if !method.GetClientStreaming() {
+		g.P("if err := x.ClientStream.SendMsg(", messageV2("in"), "); err != nil { return nil, err }")
+		g.P("if err := x.ClientStream.CloseSend(); err != nil { return nil, err }")
+	}
+	g.P("return x, nil")
+	g.P("}")
+	g.P()
+
+	g.P("type ", servName, "_", methName, "Client interface {")
+	if method.GetClientStreaming() {
+		g.P("Send(*", inType, ") error")
+	}
+	if method.GetServerStreaming() {
+		g.P("Recv() (*", outType, ", error)")
+	} else {
+		g.P("CloseAndRecv() (*", outType, ", error)")
+	}
+	g.P(frogPkg, ".ClientStream")
+	g.P("}")
+	g.P()
+
+	g.P("type ", streamType, " struct {")
+	g.P(frogPkg, ".ClientStream")
+	g.P("}")
+	g.P()
+
+	if method.GetClientStreaming() {
+		g.P("func (x *", streamType, ") Send(m *", inType, ") error {")
+		g.P("return x.ClientStream.SendMsg(", messageV2("m"), ")")
+		g.P("}")
+		g.P()
+	}
+
+	if method.GetServerStreaming() {
+		g.P("func (x *", streamType, ") Recv() (*", outType, ", error) {")
+		g.P("m := new(", outType, ")")
+		g.P("if err := x.ClientStream.RecvMsg(", messageV2("m"), "); err != nil { return nil, err }")
+		g.P("return m, nil")
+		g.P("}")
+	} else {
+		g.P("func (x *", streamType, ") CloseAndRecv() (*", outType, ", error) {")
+		g.P("if err := x.ClientStream.CloseSend(); err != nil { return nil, err }")
+		g.P("m := new(", outType, ")")
+		g.P("if err := x.ClientStream.RecvMsg(", messageV2("m"), "); err != nil { return nil, err }")
+		g.P("return m, nil")
+		g.P("}")
+	}
+	g.P()
+}
+
+// generateStubStreamSignature returns the stub signature for a streaming method.
+func (g *frogGen) generateStubStreamSignature(servName string, method *desc.MethodDescriptorProto) string {
+	methName := generator.CamelCase(method.GetName())
+	if method.GetClientStreaming() {
+		return fmt.Sprintf("%s(ctx context.Context) (%s_%sClient, error)", methName, servName, methName)
+	}
+	inType := g.typeName(method.GetInputType())
+	return fmt.Sprintf("%s(ctx context.Context, in *%s) (%s_%sClient, error)", methName, inType, servName, methName)
+}
+
+// generateStreamHandler generates the handler of a streaming method of
+// the server API iface.
+func (g *frogGen) generateStreamHandler(servName, iface string, method *desc.MethodDescriptorProto) {
+	methName := generator.CamelCase(method.GetName())
+	streamType := unexport(servName) + methName + "Server"
+
+	g.P("func _", iface, "_", methName, "_Handler(service interface{}, stream ", frogPkg, ".ServerStream) error {")
+	if method.GetClientStreaming() {
+		g.P("return service.(", iface, ").", methName, "(stream.Context(), &", streamType, "{stream})")
+	} else {
+		g.P("in := new(", g.typeName(method.GetInputType()), ")")
+		g.P("if err := stream.RecvMsg(", messageV2("in"), "); err != nil { return err }")
+		g.P("return service.(", iface, ").", methName, "(stream.Context(), in, &", streamType, "{stream})")
+	}
+	g.P("}")
+}
+
+// generateServerStream generates the handler of a streaming method,
+// and its typed server stream.
+func (g *frogGen) generateServerStream(servName string, method *desc.MethodDescriptorProto) {
+	methName := generator.CamelCase(method.GetName())
+	inType := g.typeName(method.GetInputType())
+	outType := g.typeName(method.GetOutputType())
+	streamType := unexport(servName) + methName + "Server"
+
+	g.generateStreamHandler(servName, servName, method)
+	g.P()
+
+	g.P("type ", servName, "_", methName, "Server interface {")
+	if method.GetServerStreaming() {
+		g.P("Send(*", outType, ") error")
+	} else {
+		g.P("SendAndClose(*", outType, ") error")
+	}
+	if method.GetClientStreaming() {
+		g.P("Recv() (*", inType, ", error)")
+	}
+	g.P(frogPkg, ".ServerStream")
+	g.P("}")
+	g.P()
+
+	g.P("type ", streamType, " struct {")
+	g.P(frogPkg, ".ServerStream")
+	g.P("}")
+	g.P()
+
+	if method.GetServerStreaming() {
+		g.P("func (x *", streamType, ") Send(m *", outType, ") error {")
+	} else {
+		g.P("func (x *", streamType, ") SendAndClose(m *", outType, ") error {")
+	}
+	g.P("return x.ServerStream.SendMsg(", messageV2("m"), ")")
+	g.P("}")
+
+	if method.GetClientStreaming() {
+		g.P()
+		g.P("func (x *", streamType, ") Recv() (*", inType, ", error) {")
+		g.P("m := new(", inType, ")")
+		g.P("if err := x.ServerStream.RecvMsg(", messageV2("m"), "); err != nil { return nil, err }")
+		g.P("return m, nil")
+		g.P("}")
+	}
+}
+
+// generateAsyncServerMethodSignature returns the signature of a method
+// served asynchronously, with named parameters.
+func (g *frogGen) generateAsyncServerMethodSignature(servName string, method *desc.MethodDescriptorProto) string {
+	if isStreaming(method) {
+		return g.generateServerMethodSignature(servName, method)
+	}
+	methName := generator.CamelCase(method.GetName())
+	inType := g.typeName(method.GetInputType())
+	outType := g.typeName(method.GetOutputType())
+	return methName + "(ctrl " + frogPkg + ".RpcController, in *" + inType + ", out *" + outType + ")"
+}
+
+// generateAsyncServerSignature returns the signature of a method served asynchronously.
+func (g *frogGen) generateAsyncServerSignature(servName string, method *desc.MethodDescriptorProto) string {
+	if isStreaming(method) {
+		return g.generateServerSignature(servName, method)
+	}
+	methName := generator.CamelCase(method.GetName())
+	inType := g.typeName(method.GetInputType())
+	outType := g.typeName(method.GetOutputType())
+	return methName + "(" + frogPkg + ".RpcController, *" + inType + ", *" + outType + ")"
+}
+
+// generateServerMethodSignature returns the server-side signature for a method, with named parameters.
+func (g *frogGen) generateServerMethodSignature(servName string, method *desc.MethodDescriptorProto) string {
+	methName := generator.CamelCase(method.GetName())
+	inType := g.typeName(method.GetInputType())
+	outType := g.typeName(method.GetOutputType())
+	if method.GetClientStreaming() {
+		return methName + "(ctx context.Context, stream " + servName + "_" + methName + "Server) error"
+	}
+	if method.GetServerStreaming() {
+		return methName + "(ctx context.Context, in *" + inType + ", stream " + servName + "_" + methName + "Server) error"
+	}
+	return methName + "(ctx context.Context, in *" + inType + ", out *" + outType + ") error"
+}
+
 // generateServerSignature returns the server-side signature for a method.
 func (g *frogGen) generateServerSignature(servName string, method *desc.MethodDescriptorProto) string {
 	origMethName := method.GetName()
 	methName := generator.CamelCase(origMethName)
 	inType := g.typeName(method.GetInputType())
 	outType := g.typeName(method.GetOutputType())
+	if method.GetClientStreaming() {
+		return methName + "(context.Context, " + servName + "_" + methName + "Server) error"
+	}
+	if method.GetServerStreaming() {
+		return methName + "(context.Context, *" + inType + ", " + servName + "_" + methName + "Server) error"
+	}
 	return methName + "(context.Context, *" + inType + ", *" + outType + ") error"
 }
@@ -0,0 +1,79 @@
+package plugin
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	desc "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/golang/protobuf/protoc-gen-go/generator"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// generated holds the code generated for testdata/golden.proto. The
+// generator registers the names of imported packages process-wide, so the
+// code is generated once, and not again when the test is repeated.
+var (
+	generateOnce sync.Once
+	generated    []byte
+	generateErr  error
+)
+
+func generateGolden() ([]byte, error) {
+	generateOnce.Do(func() {
+		b, err := os.ReadFile("testdata/golden.pb")
+		if err != nil {
+			generateErr = err
+			return
+		}
+		var set desc.FileDescriptorSet
+		if err := proto.Unmarshal(b, &set); err != nil {
+			generateErr = err
+			return
+		}
+
+		g := generator.New()
+		g.Request.FileToGenerate = []string{"golden.proto"}
+		g.Request.Parameter = proto.String("plugins=frog,paths=source_relative")
+		g.Request.ProtoFile = set.File
+		g.CommandLineParameters(g.Request.GetParameter())
+		g.WrapTypes()
+		g.SetPackageNames()
+		g.BuildTypeNameMap()
+		g.GenerateAllFiles()
+		if len(g.Response.File) != 1 {
+			generateErr = fmt.Errorf("%d files generated, want 1", len(g.Response.File))
+			return
+		}
+		generated = []byte(g.Response.File[0].GetContent())
+	})
+	return generated, generateErr
+}
+
+// TestGolden generates the code of testdata/golden.proto, which has streaming,
+// deprecated and commented methods, and compares it to the golden file.
+func TestGolden(t *testing.T) {
+	got, err := generateGolden()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const golden = "testdata/golden.pb.go.golden"
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated code differs from %s, rerun with -update to accept it:\n%s", golden, got)
+	}
+}
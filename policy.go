@@ -0,0 +1,176 @@
+package frog
+
+import (
+	"context"
+	"time"
+
+	"github.com/yplusplus/frog/options"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultMaxRetries   = 2
+	retryBackoff        = 10 * time.Millisecond
+	maxRetryBackoff     = time.Second
+	defaultRetryCodeSet = 1 << Unavailable
+)
+
+// CallPolicy is the call policy of a method, declared with the frog.policy
+// and frog.default_policy options of options/options.proto.
+type CallPolicy struct {
+	Timeout         time.Duration // deadline of calls whose context has none, 0 for none
+	Idempotent      bool          // whether calls can be retried safely
+	RetryCodes      []Code        // codes of failed calls which are retried
+	MaxRetries      int           // maximum number of retries of a call
+	MaxRequestBytes int           // maximum size of a request message, 0 for no limit
+
+	retryCodes uint32 // set of RetryCodes
+}
+
+// Retryable reports whether a call failed with err can be retried.
+func (p *CallPolicy) Retryable(err error) bool {
+	return p.Idempotent && err != nil && p.retryCodes&(1<<ErrorCode(err)) != 0
+}
+
+// Policy returns the call policy of the method. Options of the method
+// override the defaults of its service.
+func (md *MethodDesc) Policy() *CallPolicy {
+	md.policyOnce.Do(func() {
+		md.policy = newCallPolicy(md)
+	})
+	return md.policy
+}
+
+func newCallPolicy(md *MethodDesc) *CallPolicy {
+	merged := new(options.CallPolicy)
+	if md.service.descriptor != nil {
+		if p, ok := ServiceOption[*options.CallPolicy](md.service, options.E_DefaultPolicy); ok {
+			proto.Merge(merged, p)
+		}
+		if p, ok := MethodOption[*options.CallPolicy](md, options.E_Policy); ok {
+			if len(p.RetryCodes) > 0 {
+				merged.RetryCodes = nil
+			}
+			proto.Merge(merged, p)
+		}
+	}
+
+	policy := &CallPolicy{
+		Timeout:         time.Duration(merged.GetTimeoutMs()) * time.Millisecond,
+		Idempotent:      merged.GetIdempotent(),
+		MaxRetries:      defaultMaxRetries,
+		MaxRequestBytes: int(merged.GetMaxRequestBytes()),
+		retryCodes:      defaultRetryCodeSet,
+	}
+	if merged.MaxRetries != nil {
+		policy.MaxRetries = int(merged.GetMaxRetries())
+	}
+	if len(merged.RetryCodes) > 0 {
+		policy.retryCodes = 0
+		for _, code := range merged.RetryCodes {
+			policy.RetryCodes = append(policy.RetryCodes, Code(code))
+			policy.retryCodes |= 1 << uint32(code)
+		}
+	} else {
+		policy.RetryCodes = []Code{Unavailable}
+	}
+	return policy
+}
+
+// checkRequestSize enforces the request size limit of the method
+func checkRequestSize(md *MethodDesc, request proto.Message) error {
+	limit := md.Policy().MaxRequestBytes
+	if limit <= 0 {
+		return nil
+	}
+	if size := proto.Size(request); size > limit {
+		return Errorf(ResourceExhausted, "request of %s is %d bytes, larger than %d", md.FullName(), size, limit)
+	}
+	return nil
+}
+
+// limitedServerStream enforces the request size limit of a streaming method
+type limitedServerStream struct {
+	ServerStream
+	method *MethodDesc
+}
+
+func (s *limitedServerStream) RecvMsg(m proto.Message) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkRequestSize(s.method, m)
+}
+
+// PolicyChannel is a RpcChannel applying the call policies of methods to
+// calls on another channel. Calls whose context has no deadline get the
+// timeout of the method, and failed calls of idempotent methods are retried.
+type PolicyChannel struct {
+	channel RpcChannel
+}
+
+func NewPolicyChannel(channel RpcChannel) *PolicyChannel {
+	return &PolicyChannel{channel}
+}
+
+func (pc *PolicyChannel) Go(method *MethodDesc, ctx context.Context, request proto.Message, response proto.Message) RpcCall {
+	policy := method.Policy()
+	var cancel context.CancelFunc
+	if _, ok := ctx.Deadline(); !ok && policy.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+	}
+
+	call := pc.channel.Go(method, ctx, request, response)
+	if !policy.Idempotent {
+		if cancel != nil {
+			OnDone(call, func(RpcCall) { cancel() })
+		}
+		return call
+	}
+
+	// canceling the retried call cancels the current attempt
+	retried := NewDefaultCall(request, response)
+	go func() {
+		if cancel != nil {
+			defer cancel()
+		}
+		backoff := retryBackoff
+		for retries := 0; ; retries++ {
+			select {
+			case <-call.Done():
+			case <-retried.Done():
+				Cancel(call)
+				return
+			}
+			err := call.Error()
+			if retries >= policy.MaxRetries || !policy.Retryable(err) {
+				retried.Close(err)
+				return
+			}
+
+			t := time.NewTimer(backoff)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				retried.Close(err)
+				return
+			case <-retried.Done():
+				t.Stop()
+				return
+			}
+			if backoff *= 2; backoff > maxRetryBackoff {
+				backoff = maxRetryBackoff
+			}
+			proto.Reset(response)
+			call = pc.channel.Go(method, ctx, request, response)
+		}
+	}()
+	return retried
+}
+
+// NewStream starts a streaming call of method on the underlying channel.
+// Streaming calls are not retried.
+func (pc *PolicyChannel) NewStream(method *MethodDesc, ctx context.Context) (ClientStream, error) {
+	return NewStream(pc.channel, method, ctx)
+}
@@ -0,0 +1,243 @@
+package frog
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yplusplus/frog/options"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// policyService is a service whose methods have the call policies of
+// policyMethods, and the default policy of policyDefaults.
+var policyService = registerPolicyService()
+
+var policyDefaults = &options.CallPolicy{
+	TimeoutMs:  proto.Uint32(200),
+	RetryCodes: []options.Code{options.Code_ABORTED},
+	MaxRetries: proto.Uint32(1),
+}
+
+var policyMethods = []struct {
+	name            string
+	clientStreaming bool
+	policy          *options.CallPolicy
+}{
+	{"Retried", false, &options.CallPolicy{Idempotent: proto.Bool(true), RetryCodes: []options.Code{options.Code_UNAVAILABLE}, MaxRetries: proto.Uint32(3)}},
+	{"Plain", false, nil},
+	{"Defaults", false, &options.CallPolicy{Idempotent: proto.Bool(true)}},
+	{"Limited", false, &options.CallPolicy{MaxRequestBytes: proto.Uint32(8)}},
+	{"LimitedUpload", true, &options.CallPolicy{MaxRequestBytes: proto.Uint32(8)}},
+}
+
+func registerPolicyService() *ServiceDesc {
+	serviceOpts := new(descriptorpb.ServiceOptions)
+	proto.SetExtension(serviceOpts, options.E_DefaultPolicy, policyDefaults)
+	service := &descriptorpb.ServiceDescriptorProto{Name: proto.String("Policy"), Options: serviceOpts}
+	for _, m := range policyMethods {
+		method := &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(m.name),
+			InputType:       proto.String(".google.protobuf.StringValue"),
+			OutputType:      proto.String(".google.protobuf.StringValue"),
+			ClientStreaming: proto.Bool(m.clientStreaming),
+		}
+		if m.policy != nil {
+			method.Options = new(descriptorpb.MethodOptions)
+			proto.SetExtension(method.Options, options.E_Policy, m.policy)
+		}
+		service.Method = append(service.Method, method)
+	}
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("frog/test_policy.proto"),
+		Package:    proto.String("frog.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/wrappers.proto", "options/options.proto"},
+		Service:    []*descriptorpb.ServiceDescriptorProto{service},
+	}
+	file, err := protodesc.NewFile(fd, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	GenerateServiceDescFromFile(file)
+	return ServiceDescriptor("frog.test.Policy")
+}
+
+// flakyChannel fails the first failures calls with code, and records the
+// time and the deadline of every call.
+type flakyChannel struct {
+	failures int
+	code     Code
+
+	mu        sync.Mutex
+	times     []time.Time
+	deadlines []time.Time // zero for calls without deadline
+}
+
+func (c *flakyChannel) Go(method *MethodDesc, ctx context.Context, request proto.Message, response proto.Message) RpcCall {
+	deadline, _ := ctx.Deadline()
+	c.mu.Lock()
+	c.times = append(c.times, time.Now())
+	c.deadlines = append(c.deadlines, deadline)
+	attempt := len(c.times)
+	c.mu.Unlock()
+
+	call := NewDefaultCall(request, response)
+	if attempt <= c.failures {
+		call.Close(Errorf(c.code, "attempt %d failed", attempt))
+	} else {
+		call.Close(nil)
+	}
+	return call
+}
+
+func (c *flakyChannel) attempts() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.times)
+}
+
+func TestCallPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		method *MethodDesc
+		want   CallPolicy
+	}{
+		{testService.Method(testEcho), CallPolicy{RetryCodes: []Code{Unavailable}, MaxRetries: 2}},
+		{policyService.MethodByName("Retried"), CallPolicy{Timeout: 200 * time.Millisecond, Idempotent: true, RetryCodes: []Code{Unavailable}, MaxRetries: 3}},
+		{policyService.MethodByName("Plain"), CallPolicy{Timeout: 200 * time.Millisecond, RetryCodes: []Code{Aborted}, MaxRetries: 1}},
+		{policyService.MethodByName("Defaults"), CallPolicy{Timeout: 200 * time.Millisecond, Idempotent: true, RetryCodes: []Code{Aborted}, MaxRetries: 1}},
+		{policyService.MethodByName("Limited"), CallPolicy{Timeout: 200 * time.Millisecond, RetryCodes: []Code{Aborted}, MaxRetries: 1, MaxRequestBytes: 8}},
+	} {
+		got := *tc.method.Policy()
+		got.retryCodes = 0
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("policy of %s = %+v, want %+v", tc.method.FullName(), got, tc.want)
+		}
+	}
+}
+
+func TestPolicyChannelRetries(t *testing.T) {
+	for _, tc := range []struct {
+		method       string
+		failures     int
+		code         Code
+		wantAttempts int
+		wantCode     Code
+	}{
+		{"Retried", 2, Unavailable, 3, OK},
+		{"Retried", 5, Unavailable, 4, Unavailable},
+		{"Retried", 1, Aborted, 1, Aborted},
+		{"Plain", 1, Aborted, 1, Aborted},
+		{"Defaults", 1, Aborted, 2, OK},
+		{"Defaults", 3, Aborted, 2, Aborted},
+		{"Defaults", 1, Unavailable, 1, Unavailable},
+	} {
+		channel := &flakyChannel{failures: tc.failures, code: tc.code}
+		call := NewPolicyChannel(channel).Go(policyService.MethodByName(tc.method), context.Background(), wrapperspb.String(""), new(wrapperspb.StringValue))
+		inTime(t, "retried call", func() { <-call.Done() })
+		if n := channel.attempts(); n != tc.wantAttempts || ErrorCode(call.Error()) != tc.wantCode {
+			t.Errorf("%s failing %d times with %v: %d attempts, %v, want %d attempts, %v",
+				tc.method, tc.failures, tc.code, n, call.Error(), tc.wantAttempts, tc.wantCode)
+		}
+	}
+}
+
+func TestPolicyChannelBackoff(t *testing.T) {
+	channel := &flakyChannel{failures: 3, code: Unavailable}
+	call := NewPolicyChannel(channel).Go(policyService.MethodByName("Retried"), context.Background(), wrapperspb.String(""), new(wrapperspb.StringValue))
+	inTime(t, "retried call", func() { <-call.Done() })
+	if call.Error() != nil {
+		t.Fatal(call.Error())
+	}
+	backoff := retryBackoff
+	for i := 1; i < len(channel.times); i++ {
+		if gap := channel.times[i].Sub(channel.times[i-1]); gap < backoff {
+			t.Errorf("retry %d after %v, want at least %v", i, gap, backoff)
+		}
+		backoff *= 2
+	}
+}
+
+func TestPolicyChannelDeadline(t *testing.T) {
+	deadline := time.Now().Add(time.Hour)
+	withDeadline, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	for _, tc := range []struct {
+		name   string
+		method *MethodDesc
+		ctx    context.Context
+		want   func(time.Time) bool
+	}{
+		{"default deadline", policyService.MethodByName("Plain"), context.Background(), func(d time.Time) bool {
+			return !d.IsZero() && time.Until(d) <= 200*time.Millisecond
+		}},
+		{"deadline of ctx", policyService.MethodByName("Plain"), withDeadline, func(d time.Time) bool { return d.Equal(deadline) }},
+		{"no timeout", testService.Method(testEcho), context.Background(), func(d time.Time) bool { return d.IsZero() }},
+	} {
+		channel := new(flakyChannel)
+		call := NewPolicyChannel(channel).Go(tc.method, tc.ctx, wrapperspb.String(""), new(wrapperspb.StringValue))
+		<-call.Done()
+		if d := channel.deadlines[0]; !tc.want(d) {
+			t.Errorf("%s: deadline of the call = %v", tc.name, d)
+		}
+	}
+}
+
+// recvStream is a ServerStream receiving requests.
+type recvStream struct {
+	ServerStream
+	requests []string
+}
+
+func (s *recvStream) RecvMsg(m proto.Message) error {
+	m.(*wrapperspb.StringValue).Value, s.requests = s.requests[0], s.requests[1:]
+	return nil
+}
+
+func TestRequestSizeLimit(t *testing.T) {
+	handlers := &ServiceHandlers{
+		Dispatch: func(service interface{}, index int, ctx context.Context, request proto.Message, response proto.Message) error {
+			return nil
+		},
+		Streams: make([]StreamHandler, len(policyMethods)),
+	}
+	var received []string
+	limitedUpload := policyService.MethodByName("LimitedUpload")
+	handlers.Streams[limitedUpload.Index()] = func(service interface{}, stream ServerStream) error {
+		for {
+			m := new(wrapperspb.StringValue)
+			if err := stream.RecvMsg(m); err != nil {
+				return err
+			}
+			received = append(received, m.Value)
+		}
+	}
+	var methods []*RpcMethod
+	if err := RegisterServiceHandlers(policyService, nil, func(m []*RpcMethod) error { methods = m; return nil }, handlers); err != nil {
+		t.Fatal(err)
+	}
+
+	limited := methods[policyService.MethodByName("Limited").Index()]
+	if err := CallMethod(limited, context.Background(), wrapperspb.String("hello"), new(wrapperspb.StringValue)); err != nil {
+		t.Errorf("call with a small request: %v", err)
+	}
+	if err := CallMethod(limited, context.Background(), wrapperspb.String("hello world"), new(wrapperspb.StringValue)); ErrorCode(err) != ResourceExhausted {
+		t.Errorf("call with a large request = %v, want ResourceExhausted", err)
+	}
+	plain := methods[policyService.MethodByName("Plain").Index()]
+	if err := CallMethod(plain, context.Background(), wrapperspb.String("hello world"), new(wrapperspb.StringValue)); err != nil {
+		t.Errorf("call of a method without limit: %v", err)
+	}
+
+	stream := &recvStream{requests: []string{"hello", "hello world", "hi"}}
+	err := CallStreamMethod(methods[limitedUpload.Index()], stream)
+	if ErrorCode(err) != ResourceExhausted || !reflect.DeepEqual(received, []string{"hello"}) {
+		t.Errorf("stream with a large request = %v after %v, want ResourceExhausted after [hello]", err, received)
+	}
+}
@@ -0,0 +1,480 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: reflection/reflection.proto
+
+package reflection
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+import (
+	"context"
+	frog "github.com/yplusplus/frog"
+	protoV2 "google.golang.org/protobuf/proto"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type ListServicesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListServicesRequest) Reset()         { *m = ListServicesRequest{} }
+func (m *ListServicesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListServicesRequest) ProtoMessage()    {}
+func (*ListServicesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_74c8213c4e7774bb, []int{0}
+}
+
+func (m *ListServicesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListServicesRequest.Unmarshal(m, b)
+}
+func (m *ListServicesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListServicesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListServicesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListServicesRequest.Merge(m, src)
+}
+func (m *ListServicesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListServicesRequest.Size(m)
+}
+func (m *ListServicesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListServicesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListServicesRequest proto.InternalMessageInfo
+
+type ListServicesResponse struct {
+	// Fully-qualified service names, e.g. "frog.health.Health".
+	Service              []string `protobuf:"bytes,1,rep,name=service,proto3" json:"service,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListServicesResponse) Reset()         { *m = ListServicesResponse{} }
+func (m *ListServicesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListServicesResponse) ProtoMessage()    {}
+func (*ListServicesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_74c8213c4e7774bb, []int{1}
+}
+
+func (m *ListServicesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListServicesResponse.Unmarshal(m, b)
+}
+func (m *ListServicesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListServicesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListServicesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListServicesResponse.Merge(m, src)
+}
+func (m *ListServicesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListServicesResponse.Size(m)
+}
+func (m *ListServicesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListServicesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListServicesResponse proto.InternalMessageInfo
+
+func (m *ListServicesResponse) GetService() []string {
+	if m != nil {
+		return m.Service
+	}
+	return nil
+}
+
+type FileByFilenameRequest struct {
+	Filename             string   `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FileByFilenameRequest) Reset()         { *m = FileByFilenameRequest{} }
+func (m *FileByFilenameRequest) String() string { return proto.CompactTextString(m) }
+func (*FileByFilenameRequest) ProtoMessage()    {}
+func (*FileByFilenameRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_74c8213c4e7774bb, []int{2}
+}
+
+func (m *FileByFilenameRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FileByFilenameRequest.Unmarshal(m, b)
+}
+func (m *FileByFilenameRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FileByFilenameRequest.Marshal(b, m, deterministic)
+}
+func (m *FileByFilenameRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FileByFilenameRequest.Merge(m, src)
+}
+func (m *FileByFilenameRequest) XXX_Size() int {
+	return xxx_messageInfo_FileByFilenameRequest.Size(m)
+}
+func (m *FileByFilenameRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FileByFilenameRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FileByFilenameRequest proto.InternalMessageInfo
+
+func (m *FileByFilenameRequest) GetFilename() string {
+	if m != nil {
+		return m.Filename
+	}
+	return ""
+}
+
+type FileContainingSymbolRequest struct {
+	// Fully-qualified name of a service, method, message, enum or extension.
+	Symbol               string   `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FileContainingSymbolRequest) Reset()         { *m = FileContainingSymbolRequest{} }
+func (m *FileContainingSymbolRequest) String() string { return proto.CompactTextString(m) }
+func (*FileContainingSymbolRequest) ProtoMessage()    {}
+func (*FileContainingSymbolRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_74c8213c4e7774bb, []int{3}
+}
+
+func (m *FileContainingSymbolRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FileContainingSymbolRequest.Unmarshal(m, b)
+}
+func (m *FileContainingSymbolRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FileContainingSymbolRequest.Marshal(b, m, deterministic)
+}
+func (m *FileContainingSymbolRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FileContainingSymbolRequest.Merge(m, src)
+}
+func (m *FileContainingSymbolRequest) XXX_Size() int {
+	return xxx_messageInfo_FileContainingSymbolRequest.Size(m)
+}
+func (m *FileContainingSymbolRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FileContainingSymbolRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FileContainingSymbolRequest proto.InternalMessageInfo
+
+func (m *FileContainingSymbolRequest) GetSymbol() string {
+	if m != nil {
+		return m.Symbol
+	}
+	return ""
+}
+
+type FileDescriptorResponse struct {
+	// Serialized FileDescriptorProtos, the requested file first and then
+	// its transitive dependencies.
+	FileDescriptorProto  [][]byte `protobuf:"bytes,1,rep,name=file_descriptor_proto,json=fileDescriptorProto,proto3" json:"file_descriptor_proto,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *FileDescriptorResponse) Reset()         { *m = FileDescriptorResponse{} }
+func (m *FileDescriptorResponse) String() string { return proto.CompactTextString(m) }
+func (*FileDescriptorResponse) ProtoMessage()    {}
+func (*FileDescriptorResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_74c8213c4e7774bb, []int{4}
+}
+
+func (m *FileDescriptorResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_FileDescriptorResponse.Unmarshal(m, b)
+}
+func (m *FileDescriptorResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_FileDescriptorResponse.Marshal(b, m, deterministic)
+}
+func (m *FileDescriptorResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FileDescriptorResponse.Merge(m, src)
+}
+func (m *FileDescriptorResponse) XXX_Size() int {
+	return xxx_messageInfo_FileDescriptorResponse.Size(m)
+}
+func (m *FileDescriptorResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_FileDescriptorResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_FileDescriptorResponse proto.InternalMessageInfo
+
+func (m *FileDescriptorResponse) GetFileDescriptorProto() [][]byte {
+	if m != nil {
+		return m.FileDescriptorProto
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*ListServicesRequest)(nil), "frog.reflection.ListServicesRequest")
+	proto.RegisterType((*ListServicesResponse)(nil), "frog.reflection.ListServicesResponse")
+	proto.RegisterType((*FileByFilenameRequest)(nil), "frog.reflection.FileByFilenameRequest")
+	proto.RegisterType((*FileContainingSymbolRequest)(nil), "frog.reflection.FileContainingSymbolRequest")
+	proto.RegisterType((*FileDescriptorResponse)(nil), "frog.reflection.FileDescriptorResponse")
+}
+
+func init() { proto.RegisterFile("reflection/reflection.proto", fileDescriptor_74c8213c4e7774bb) }
+
+var fileDescriptor_74c8213c4e7774bb = []byte{
+	// 306 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x92, 0xc1, 0x4e, 0xb3, 0x40,
+	0x10, 0xc7, 0xc3, 0xf7, 0x25, 0xd5, 0x4e, 0x1a, 0x4d, 0xb6, 0xa5, 0x21, 0xf4, 0xd2, 0x90, 0x5a,
+	0x7b, 0x30, 0x60, 0xda, 0xf8, 0x02, 0xd5, 0x78, 0xea, 0xc1, 0xd0, 0x9b, 0x1e, 0x1a, 0xc0, 0x05,
+	0x37, 0x81, 0x5d, 0xdc, 0x5d, 0x4c, 0x78, 0x36, 0x5f, 0xce, 0x2c, 0x6e, 0x69, 0xd1, 0x35, 0x7a,
+	0x80, 0x30, 0xff, 0x99, 0xff, 0x30, 0xf3, 0xdb, 0x85, 0x09, 0xc7, 0x69, 0x8e, 0x13, 0x49, 0x18,
+	0x0d, 0x0e, 0x9f, 0x7e, 0xc9, 0x99, 0x64, 0xe8, 0x3c, 0xe5, 0x2c, 0xf3, 0x0f, 0xb2, 0x67, 0xc3,
+	0x70, 0x43, 0x84, 0xdc, 0x62, 0xfe, 0x46, 0x12, 0x2c, 0x42, 0xfc, 0x5a, 0x61, 0x21, 0xbd, 0x6b,
+	0x18, 0x75, 0x65, 0x51, 0x32, 0x2a, 0x30, 0x72, 0xe0, 0x44, 0x7c, 0x6a, 0x8e, 0x35, 0xfd, 0xbf,
+	0xe8, 0x87, 0xfb, 0xd0, 0x5b, 0x81, 0x7d, 0x4f, 0x72, 0xbc, 0xae, 0xd5, 0x9b, 0x46, 0x05, 0xd6,
+	0xad, 0x90, 0x0b, 0xa7, 0xa9, 0x96, 0x1c, 0x6b, 0x6a, 0x2d, 0xfa, 0x61, 0x1b, 0x7b, 0x37, 0x30,
+	0x51, 0xe5, 0xb7, 0x8c, 0xca, 0x88, 0x50, 0x42, 0xb3, 0x6d, 0x5d, 0xc4, 0x2c, 0xdf, 0x5b, 0xc7,
+	0xd0, 0x13, 0x8d, 0xa0, 0x8d, 0x3a, 0xf2, 0x36, 0x30, 0x56, 0xb6, 0x3b, 0x2c, 0x12, 0x4e, 0x4a,
+	0xc9, 0x78, 0x3b, 0xdf, 0x12, 0x6c, 0xd5, 0x7c, 0xf7, 0xdc, 0xa6, 0x76, 0xcd, 0xe2, 0xcd, 0xb4,
+	0x83, 0x70, 0x98, 0x76, 0x6c, 0x0f, 0x2a, 0xb5, 0x7c, 0xff, 0x07, 0x10, 0xb6, 0x44, 0xd0, 0x13,
+	0x0c, 0x8e, 0x57, 0x47, 0x33, 0xff, 0x0b, 0x33, 0xdf, 0x00, 0xcc, 0xbd, 0xf8, 0xa5, 0x4a, 0xcf,
+	0x17, 0xc1, 0x59, 0x97, 0x12, 0x9a, 0x7f, 0x33, 0x1a, 0x31, 0xba, 0x97, 0xc6, 0x3a, 0x03, 0x82,
+	0x02, 0x46, 0x26, 0xa6, 0xe8, 0xca, 0xd8, 0xe0, 0x07, 0xf4, 0x7f, 0xfe, 0xdd, 0x7a, 0xfe, 0x38,
+	0xcb, 0x88, 0x7c, 0xa9, 0x62, 0x3f, 0x61, 0x45, 0x50, 0x97, 0x79, 0x25, 0xd4, 0x13, 0x28, 0xfb,
+	0xd1, 0xfd, 0x8b, 0x7b, 0xcd, 0x39, 0xac, 0x3e, 0x06, 0x00, 0xe9, 0x20, 0xfb, 0xd5, 0x9f, 0x02,
+	0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+
+// Stub for Reflection service
+
+// Full names of the methods of Reflection service, as returned by MethodDesc.FullName.
+const (
+	Reflection_ListServices_FullMethodName         = "/frog.reflection.Reflection/ListServices"
+	Reflection_FileByFilename_FullMethodName       = "/frog.reflection.Reflection/FileByFilename"
+	Reflection_FileContainingSymbol_FullMethodName = "/frog.reflection.Reflection/FileContainingSymbol"
+)
+
+// Reflection_ListServices_MethodDesc returns the descriptor of the ListServices method of Reflection service.
+func Reflection_ListServices_MethodDesc() *frog.MethodDesc {
+	return Reflection_ServiceDesc.Method(0)
+}
+
+// Reflection_FileByFilename_MethodDesc returns the descriptor of the FileByFilename method of Reflection service.
+func Reflection_FileByFilename_MethodDesc() *frog.MethodDesc {
+	return Reflection_ServiceDesc.Method(1)
+}
+
+// Reflection_FileContainingSymbol_MethodDesc returns the descriptor of the FileContainingSymbol method of Reflection service.
+func Reflection_FileContainingSymbol_MethodDesc() *frog.MethodDesc {
+	return Reflection_ServiceDesc.Method(2)
+}
+
+// ReflectionClient is the client API for Reflection service.
+//
+// Reflection exposes the services of a frog server and their descriptors,
+// so generic tools can call them without the .proto files.
+type ReflectionClient interface {
+	ListServices(ctx context.Context, in *ListServicesRequest, out *ListServicesResponse) error
+	// AsyncListServices is the asynchronous version of ListServices.
+	AsyncListServices(ctx context.Context, in *ListServicesRequest, out *ListServicesResponse) *frog.TypedCall[*ListServicesResponse]
+	FileByFilename(ctx context.Context, in *FileByFilenameRequest, out *FileDescriptorResponse) error
+	// AsyncFileByFilename is the asynchronous version of FileByFilename.
+	AsyncFileByFilename(ctx context.Context, in *FileByFilenameRequest, out *FileDescriptorResponse) *frog.TypedCall[*FileDescriptorResponse]
+	FileContainingSymbol(ctx context.Context, in *FileContainingSymbolRequest, out *FileDescriptorResponse) error
+	// AsyncFileContainingSymbol is the asynchronous version of FileContainingSymbol.
+	AsyncFileContainingSymbol(ctx context.Context, in *FileContainingSymbolRequest, out *FileDescriptorResponse) *frog.TypedCall[*FileDescriptorResponse]
+}
+
+type reflectionStub struct {
+	channel frog.RpcChannel
+}
+
+// NewReflectionStub returns the ReflectionClient calling methods on channel.
+//
+// Reflection exposes the services of a frog server and their descriptors,
+// so generic tools can call them without the .proto files.
+func NewReflectionStub(channel frog.RpcChannel) ReflectionClient {
+	return &reflectionStub{channel}
+}
+
+func (stub *reflectionStub) ListServices(ctx context.Context, in *ListServicesRequest, out *ListServicesResponse) error {
+	err := stub.Call(Reflection_ListServices_MethodDesc(), ctx, in, out)
+	return err
+}
+
+// AsyncListServices is the asynchronous version of ListServices.
+func (stub *reflectionStub) AsyncListServices(ctx context.Context, in *ListServicesRequest, out *ListServicesResponse) *frog.TypedCall[*ListServicesResponse] {
+	return frog.NewTypedCall(stub.Go(Reflection_ListServices_MethodDesc(), ctx, in, out), out)
+}
+
+func (stub *reflectionStub) FileByFilename(ctx context.Context, in *FileByFilenameRequest, out *FileDescriptorResponse) error {
+	err := stub.Call(Reflection_FileByFilename_MethodDesc(), ctx, in, out)
+	return err
+}
+
+// AsyncFileByFilename is the asynchronous version of FileByFilename.
+func (stub *reflectionStub) AsyncFileByFilename(ctx context.Context, in *FileByFilenameRequest, out *FileDescriptorResponse) *frog.TypedCall[*FileDescriptorResponse] {
+	return frog.NewTypedCall(stub.Go(Reflection_FileByFilename_MethodDesc(), ctx, in, out), out)
+}
+
+func (stub *reflectionStub) FileContainingSymbol(ctx context.Context, in *FileContainingSymbolRequest, out *FileDescriptorResponse) error {
+	err := stub.Call(Reflection_FileContainingSymbol_MethodDesc(), ctx, in, out)
+	return err
+}
+
+// AsyncFileContainingSymbol is the asynchronous version of FileContainingSymbol.
+func (stub *reflectionStub) AsyncFileContainingSymbol(ctx context.Context, in *FileContainingSymbolRequest, out *FileDescriptorResponse) *frog.TypedCall[*FileDescriptorResponse] {
+	return frog.NewTypedCall(stub.Go(Reflection_FileContainingSymbol_MethodDesc(), ctx, in, out), out)
+}
+
+func (stub *reflectionStub) Call(method *frog.MethodDesc, ctx context.Context, in proto.Message, out proto.Message) error {
+	call := stub.channel.Go(method, ctx, proto.MessageV2(in), proto.MessageV2(out))
+	<-call.Done()
+	return call.Error()
+}
+
+func (stub *reflectionStub) Go(method *frog.MethodDesc, ctx context.Context, in proto.Message, out proto.Message) frog.RpcCall {
+	return stub.channel.Go(method, ctx, proto.MessageV2(in), proto.MessageV2(out))
+}
+
+// Reflection is the server API for Reflection service.
+//
+// Reflection exposes the services of a frog server and their descriptors,
+// so generic tools can call them without the .proto files.
+type Reflection interface {
+	ListServices(context.Context, *ListServicesRequest, *ListServicesResponse) error
+	FileByFilename(context.Context, *FileByFilenameRequest, *FileDescriptorResponse) error
+	FileContainingSymbol(context.Context, *FileContainingSymbolRequest, *FileDescriptorResponse) error
+}
+
+// UnimplementedReflection should be embedded by implementations of Reflection,
+// so they keep compiling and registering when methods are added to the service.
+type UnimplementedReflection struct{}
+
+func (UnimplementedReflection) ListServices(ctx context.Context, in *ListServicesRequest, out *ListServicesResponse) error {
+	return frog.Errorf(frog.Unimplemented, "method ListServices not implemented")
+}
+
+func (UnimplementedReflection) FileByFilename(ctx context.Context, in *FileByFilenameRequest, out *FileDescriptorResponse) error {
+	return frog.Errorf(frog.Unimplemented, "method FileByFilename not implemented")
+}
+
+func (UnimplementedReflection) FileContainingSymbol(ctx context.Context, in *FileContainingSymbolRequest, out *FileDescriptorResponse) error {
+	return frog.Errorf(frog.Unimplemented, "method FileContainingSymbol not implemented")
+}
+
+func RegisterReflection(service Reflection, register frog.MethodsRegister) error {
+	return frog.RegisterServiceHandlers(Reflection_ServiceDesc, service, register, _Reflection_Handlers)
+}
+
+var _Reflection_Handlers = &frog.ServiceHandlers{
+	Dispatch:    _Reflection_Dispatch,
+	NewRequest:  _Reflection_NewRequest,
+	NewResponse: _Reflection_NewResponse,
+}
+
+func _Reflection_Dispatch(service interface{}, index int, ctx context.Context, in protoV2.Message, out protoV2.Message) error {
+	switch index {
+	case 0:
+		return service.(Reflection).ListServices(ctx, proto.MessageV1(in).(*ListServicesRequest), proto.MessageV1(out).(*ListServicesResponse))
+	case 1:
+		return service.(Reflection).FileByFilename(ctx, proto.MessageV1(in).(*FileByFilenameRequest), proto.MessageV1(out).(*FileDescriptorResponse))
+	case 2:
+		return service.(Reflection).FileContainingSymbol(ctx, proto.MessageV1(in).(*FileContainingSymbolRequest), proto.MessageV1(out).(*FileDescriptorResponse))
+	}
+	return frog.Errorf(frog.Unimplemented, "Reflection has no unary method %d", index)
+}
+
+func _Reflection_NewRequest(index int) protoV2.Message {
+	switch index {
+	case 0:
+		return proto.MessageV2(new(ListServicesRequest))
+	case 1:
+		return proto.MessageV2(new(FileByFilenameRequest))
+	case 2:
+		return proto.MessageV2(new(FileContainingSymbolRequest))
+	}
+	return nil
+}
+
+func _Reflection_NewResponse(index int) protoV2.Message {
+	switch index {
+	case 0:
+		return proto.MessageV2(new(ListServicesResponse))
+	case 1:
+		return proto.MessageV2(new(FileDescriptorResponse))
+	case 2:
+		return proto.MessageV2(new(FileDescriptorResponse))
+	}
+	return nil
+}
+
+// ReflectionAsync is the server API for Reflection service served asynchronously:
+// unary methods may return at once and complete the call later with ctrl.Done.
+//
+// Reflection exposes the services of a frog server and their descriptors,
+// so generic tools can call them without the .proto files.
+type ReflectionAsync interface {
+	ListServices(frog.RpcController, *ListServicesRequest, *ListServicesResponse)
+	FileByFilename(frog.RpcController, *FileByFilenameRequest, *FileDescriptorResponse)
+	FileContainingSymbol(frog.RpcController, *FileContainingSymbolRequest, *FileDescriptorResponse)
+}
+
+// UnimplementedReflectionAsync should be embedded by implementations of ReflectionAsync,
+// so they keep compiling and registering when methods are added to the service.
+type UnimplementedReflectionAsync struct{}
+
+func (UnimplementedReflectionAsync) ListServices(ctrl frog.RpcController, in *ListServicesRequest, out *ListServicesResponse) {
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "method ListServices not implemented"))
+}
+
+func (UnimplementedReflectionAsync) FileByFilename(ctrl frog.RpcController, in *FileByFilenameRequest, out *FileDescriptorResponse) {
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "method FileByFilename not implemented"))
+}
+
+func (UnimplementedReflectionAsync) FileContainingSymbol(ctrl frog.RpcController, in *FileContainingSymbolRequest, out *FileDescriptorResponse) {
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "method FileContainingSymbol not implemented"))
+}
+
+func RegisterReflectionAsync(service ReflectionAsync, register frog.MethodsRegister) error {
+	return frog.RegisterServiceHandlers(Reflection_ServiceDesc, service, register, _ReflectionAsync_Handlers)
+}
+
+var _ReflectionAsync_Handlers = &frog.ServiceHandlers{
+	AsyncDispatch: _ReflectionAsync_Dispatch,
+	NewRequest:    _Reflection_NewRequest,
+	NewResponse:   _Reflection_NewResponse,
+}
+
+func _ReflectionAsync_Dispatch(service interface{}, index int, ctrl frog.RpcController, in protoV2.Message, out protoV2.Message) {
+	switch index {
+	case 0:
+		service.(ReflectionAsync).ListServices(ctrl, proto.MessageV1(in).(*ListServicesRequest), proto.MessageV1(out).(*ListServicesResponse))
+		return
+	case 1:
+		service.(ReflectionAsync).FileByFilename(ctrl, proto.MessageV1(in).(*FileByFilenameRequest), proto.MessageV1(out).(*FileDescriptorResponse))
+		return
+	case 2:
+		service.(ReflectionAsync).FileContainingSymbol(ctrl, proto.MessageV1(in).(*FileContainingSymbolRequest), proto.MessageV1(out).(*FileDescriptorResponse))
+		return
+	}
+	ctrl.Done(frog.Errorf(frog.Unimplemented, "Reflection has no unary method %d", index))
+}
+
+var (
+	Reflection_ServiceDesc *frog.ServiceDesc
+)
+
+func init() {
+	frog.GenerateServiceDesc(fileDescriptor_74c8213c4e7774bb)
+	Reflection_ServiceDesc = frog.ServiceDescriptor("frog.reflection.Reflection")
+}
@@ -0,0 +1,186 @@
+// Package reflection implements the frog.reflection.Reflection service,
+// which exposes registered services and their file descriptors.
+package reflection
+
+import (
+	"context"
+	"sync"
+
+	desc "github.com/golang/protobuf/protoc-gen-go/descriptor"
+	"github.com/yplusplus/frog"
+	"google.golang.org/protobuf/proto"
+)
+
+// Server implements the Reflection service.
+type Server struct {
+	services []*frog.ServiceDesc
+
+	mu      sync.Mutex // protect following fields
+	indexed []*frog.ServiceDesc
+	idx     *index // of the files of indexed
+}
+
+// index maps the files reachable from the exposed services, and their symbols.
+type index struct {
+	files   map[string]*desc.FileDescriptorProto // file name to its file
+	symbols map[string]*desc.FileDescriptorProto // fully-qualified symbol to its file
+}
+
+// NewServer returns a reflection server exposing given services,
+// or every registered service if none is given.
+func NewServer(services ...*frog.ServiceDesc) *Server {
+	return &Server{services: services}
+}
+
+func (s *Server) serviceList() []*frog.ServiceDesc {
+	if len(s.services) == 0 {
+		return frog.Services()
+	}
+	return s.services
+}
+
+func (s *Server) ListServices(ctx context.Context, in *ListServicesRequest, out *ListServicesResponse) error {
+	for _, sd := range s.serviceList() {
+		out.Service = append(out.Service, sd.FullName())
+	}
+	return nil
+}
+
+// FileByFilename returns the named file and its dependencies, if the file
+// is one of the exposed services or their dependencies.
+func (s *Server) FileByFilename(ctx context.Context, in *FileByFilenameRequest, out *FileDescriptorResponse) error {
+	idx := s.index()
+	fd, ok := idx.files[in.GetFilename()]
+	if !ok {
+		return frog.Errorf(frog.NotFound, "unknown file %q", in.GetFilename())
+	}
+	return idx.fillFiles(fd, out)
+}
+
+func (s *Server) FileContainingSymbol(ctx context.Context, in *FileContainingSymbolRequest, out *FileDescriptorResponse) error {
+	idx := s.index()
+	fd, ok := idx.symbols[in.GetSymbol()]
+	if !ok {
+		return frog.Errorf(frog.NotFound, "unknown symbol %q", in.GetSymbol())
+	}
+	return idx.fillFiles(fd, out)
+}
+
+// index returns the index of the exposed services, built again when
+// services are registered after the last one.
+func (s *Server) index() *index {
+	services := s.serviceList()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.idx == nil || !sameServices(services, s.indexed) {
+		s.idx = buildIndex(services)
+		s.indexed = services
+	}
+	return s.idx
+}
+
+func sameServices(a, b []*frog.ServiceDesc) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// fillFiles puts fd and its transitive dependencies into out.
+func (idx *index) fillFiles(fd *desc.FileDescriptorProto, out *FileDescriptorResponse) error {
+	seen := make(map[string]bool)
+	var add func(fd *desc.FileDescriptorProto) error
+	add = func(fd *desc.FileDescriptorProto) error {
+		if seen[fd.GetName()] {
+			return nil
+		}
+		seen[fd.GetName()] = true
+
+		b, err := proto.Marshal(fd)
+		if err != nil {
+			return frog.Errorf(frog.Internal, "marshal %s failed: %v", fd.GetName(), err)
+		}
+		out.FileDescriptorProto = append(out.FileDescriptorProto, b)
+
+		for _, dep := range fd.GetDependency() {
+			depFd, ok := idx.files[dep]
+			if !ok {
+				return frog.Errorf(frog.NotFound, "dependency %q of %s not found", dep, fd.GetName())
+			}
+			if err := add(depFd); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return add(fd)
+}
+
+// buildIndex maps the files of services, and their dependencies, and every
+// symbol they define to the file defining it.
+func buildIndex(services []*frog.ServiceDesc) *index {
+	idx := &index{
+		files:   make(map[string]*desc.FileDescriptorProto),
+		symbols: make(map[string]*desc.FileDescriptorProto),
+	}
+	for _, sd := range services {
+		idx.indexFile(sd.File())
+	}
+	return idx
+}
+
+func (idx *index) indexFile(fd *desc.FileDescriptorProto) {
+	if fd == nil {
+		return
+	}
+	if _, ok := idx.files[fd.GetName()]; ok {
+		return
+	}
+	idx.files[fd.GetName()] = fd
+
+	prefix := ""
+	if pkg := fd.GetPackage(); pkg != "" {
+		prefix = pkg + "."
+	}
+	for _, m := range fd.GetMessageType() {
+		idx.indexMessage(fd, prefix, m)
+	}
+	for _, e := range fd.GetEnumType() {
+		idx.symbols[prefix+e.GetName()] = fd
+	}
+	for _, ext := range fd.GetExtension() {
+		idx.symbols[prefix+ext.GetName()] = fd
+	}
+	for _, service := range fd.GetService() {
+		name := prefix + service.GetName()
+		idx.symbols[name] = fd
+		for _, method := range service.GetMethod() {
+			idx.symbols[name+"."+method.GetName()] = fd
+		}
+	}
+
+	for _, dep := range fd.GetDependency() {
+		idx.indexFile(frog.FileDescriptor(dep))
+	}
+}
+
+func (idx *index) indexMessage(fd *desc.FileDescriptorProto, prefix string, m *desc.DescriptorProto) {
+	name := prefix + m.GetName()
+	idx.symbols[name] = fd
+	for _, nested := range m.GetNestedType() {
+		idx.indexMessage(fd, name+".", nested)
+	}
+	for _, e := range m.GetEnumType() {
+		idx.symbols[name+"."+e.GetName()] = fd
+	}
+	for _, ext := range m.GetExtension() {
+		idx.symbols[name+"."+ext.GetName()] = fd
+	}
+}
+
+var _ Reflection = (*Server)(nil)
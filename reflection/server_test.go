@@ -0,0 +1,136 @@
+package reflection
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/yplusplus/frog"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// registerFile registers a file defining service, with the method Find taking
+// the nested message Outer.Inner.
+func registerFile(t *testing.T, filename, service string) {
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String(filename),
+		Package:    proto.String("frog.reflecttest"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/wrappers.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name:       proto.String(service + "Outer"),
+			NestedType: []*descriptorpb.DescriptorProto{{Name: proto.String("Inner")}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String(service),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("Find"),
+				InputType:  proto.String(".frog.reflecttest." + service + "Outer.Inner"),
+				OutputType: proto.String(".google.protobuf.StringValue"),
+			}},
+		}},
+	}
+	file, err := protodesc.NewFile(fd, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frog.GenerateServiceDescFromFile(file)
+}
+
+// fileNames returns the names of the files of out.
+func fileNames(t *testing.T, out *FileDescriptorResponse) []string {
+	var names []string
+	for _, b := range out.FileDescriptorProto {
+		fd := new(descriptorpb.FileDescriptorProto)
+		if err := proto.Unmarshal(b, fd); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, fd.GetName())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReflection(t *testing.T) {
+	registerFile(t, "frog/reflecttest.proto", "Lookup")
+	ctx := context.Background()
+	s := NewServer()
+
+	var list ListServicesResponse
+	if err := s.ListServices(ctx, &ListServicesRequest{}, &list); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"frog.reflection.Reflection", "frog.reflecttest.Lookup"} {
+		if i := sort.SearchStrings(list.Service, want); i == len(list.Service) || list.Service[i] != want {
+			t.Errorf("services = %v, want %s listed", list.Service, want)
+		}
+	}
+
+	// files come with their transitive dependencies
+	var out FileDescriptorResponse
+	if err := s.FileByFilename(ctx, &FileByFilenameRequest{Filename: "frog/reflecttest.proto"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fileNames(t, &out), []string{"frog/reflecttest.proto", "google/protobuf/wrappers.proto"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+
+	for _, symbol := range []string{
+		"frog.reflecttest.Lookup",
+		"frog.reflecttest.Lookup.Find",
+		"frog.reflecttest.LookupOuter.Inner",
+		"google.protobuf.StringValue",
+	} {
+		out := new(FileDescriptorResponse)
+		if err := s.FileContainingSymbol(ctx, &FileContainingSymbolRequest{Symbol: symbol}, out); err != nil {
+			t.Errorf("file of %s: %v", symbol, err)
+			continue
+		}
+		if names := fileNames(t, out); len(names) == 0 {
+			t.Errorf("no file of %s", symbol)
+		}
+	}
+
+	for _, err := range []error{
+		s.FileByFilename(ctx, &FileByFilenameRequest{Filename: "frog/unknown.proto"}, new(FileDescriptorResponse)),
+		s.FileContainingSymbol(ctx, &FileContainingSymbolRequest{Symbol: "frog.reflecttest.Unknown"}, new(FileDescriptorResponse)),
+		s.FileContainingSymbol(ctx, &FileContainingSymbolRequest{Symbol: "frog.reflecttest.Lookup.Unknown"}, new(FileDescriptorResponse)),
+	} {
+		if frog.ErrorCode(err) != frog.NotFound {
+			t.Errorf("lookup of unknown = %v, want NotFound", err)
+		}
+	}
+
+	// services registered later are found too
+	registerFile(t, "frog/reflecttest_late.proto", "Late")
+	if err := s.FileContainingSymbol(ctx, &FileContainingSymbolRequest{Symbol: "frog.reflecttest.Late.Find"}, new(FileDescriptorResponse)); err != nil {
+		t.Errorf("file of a service registered later: %v", err)
+	}
+}
+
+func TestReflectionOfGivenServices(t *testing.T) {
+	ctx := context.Background()
+	s := NewServer(Reflection_ServiceDesc)
+
+	var list ListServicesResponse
+	s.ListServices(ctx, &ListServicesRequest{}, &list)
+	if want := []string{"frog.reflection.Reflection"}; !reflect.DeepEqual(list.Service, want) {
+		t.Errorf("services = %v, want %v", list.Service, want)
+	}
+	if err := s.FileByFilename(ctx, &FileByFilenameRequest{Filename: "reflection/reflection.proto"}, new(FileDescriptorResponse)); err != nil {
+		t.Errorf("file of the service: %v", err)
+	}
+
+	// files of other services are not exposed
+	if err := s.FileByFilename(ctx, &FileByFilenameRequest{Filename: "google/protobuf/wrappers.proto"}, new(FileDescriptorResponse)); frog.ErrorCode(err) != frog.NotFound {
+		t.Errorf("file of no given service = %v, want NotFound", err)
+	}
+	if err := s.FileContainingSymbol(ctx, &FileContainingSymbolRequest{Symbol: "google.protobuf.StringValue"}, new(FileDescriptorResponse)); frog.ErrorCode(err) != frog.NotFound {
+		t.Errorf("symbol of no given service = %v, want NotFound", err)
+	}
+}
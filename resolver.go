@@ -0,0 +1,279 @@
+package frog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrWatcherClosed is returned by Watcher.Next after the watcher is closed.
+	ErrWatcherClosed = errors.New("frog: watcher closed")
+)
+
+const defaultResolveInterval = 30 * time.Second
+
+// Resolver resolves a target into a watchable set of backend addresses.
+type Resolver interface {
+	// Resolve starts watching the target, e.g. "dns:///_echo._tcp.svc".
+	Resolve(target *url.URL) (Watcher, error)
+}
+
+// Watcher yields the address set of a target every time it changes.
+type Watcher interface {
+	// Next blocks until the address set changes and returns the whole set.
+	// The first call returns the initial set.
+	Next() ([]string, error)
+
+	// Close stops watching, and blocked or later Next calls return ErrWatcherClosed.
+	Close() error
+}
+
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]Resolver{
+		"static": StaticResolver{},
+		"file":   &FileResolver{},
+		"dns":    &DNSResolver{},
+	}
+)
+
+// RegisterResolver registers resolver for the target scheme, replacing the existing one.
+func RegisterResolver(scheme string, resolver Resolver) {
+	resolversMu.Lock()
+	resolvers[scheme] = resolver
+	resolversMu.Unlock()
+}
+
+// Resolve parses target and watches it with the resolver registered for its scheme.
+func Resolve(target string) (Watcher, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("frog: bad target %q: %v", target, err)
+	}
+
+	resolversMu.RLock()
+	resolver, ok := resolvers[u.Scheme]
+	resolversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("frog: no resolver for scheme %q", u.Scheme)
+	}
+	return resolver.Resolve(u)
+}
+
+// DialTarget creates a balancing channel whose backends follow the target's
+// resolved addresses. It waits for the initial address set.
+func DialTarget(target string, dial Dialer, policy BalancePolicy) (*BalancingChannel, error) {
+	w, err := Resolve(target)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := w.Next()
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+
+	bc, err := NewBalancingChannel(dial, policy, addrs...)
+	if err != nil && len(bc.Backends()) == 0 {
+		w.Close()
+		return nil, err
+	}
+	bc.watch(w)
+	return bc, nil
+}
+
+// StaticResolver resolves "static:///host1:port1,host2:port2" to a fixed address set.
+type StaticResolver struct{}
+
+func (StaticResolver) Resolve(target *url.URL) (Watcher, error) {
+	var addrs []string
+	for _, addr := range strings.Split(strings.TrimPrefix(target.Path, "/"), ",") {
+		if addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return &staticWatcher{addrs: addrs, closed: make(chan struct{})}, nil
+}
+
+type staticWatcher struct {
+	addrs  []string
+	sent   bool
+	once   sync.Once
+	closed chan struct{}
+}
+
+func (w *staticWatcher) Next() ([]string, error) {
+	if !w.sent {
+		w.sent = true
+		return w.addrs, nil
+	}
+	<-w.closed
+	return nil, ErrWatcherClosed
+}
+
+func (w *staticWatcher) Close() error {
+	w.once.Do(func() { close(w.closed) })
+	return nil
+}
+
+// FileResolver resolves "file:///etc/frog/echo.json", a JSON array of addresses,
+// and re-reads the file every Interval.
+type FileResolver struct {
+	Interval time.Duration // 30 seconds by default
+}
+
+func (r *FileResolver) Resolve(target *url.URL) (Watcher, error) {
+	path := target.Path
+	if path == "" {
+		path = target.Opaque
+	}
+	if path == "" {
+		return nil, fmt.Errorf("frog: file target %q has no path", target)
+	}
+
+	fetch := func() ([]string, error) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var addrs []string
+		if err := json.Unmarshal(b, &addrs); err != nil {
+			return nil, fmt.Errorf("frog: parse %s failed: %v", path, err)
+		}
+		return addrs, nil
+	}
+	return newPollWatcher(r.Interval, fetch), nil
+}
+
+// DNSResolver resolves "dns:///_echo._tcp.svc" with SRV lookups every Interval.
+// The target authority, as in "dns://127.0.0.1:53/_echo._tcp.svc", selects the DNS server.
+type DNSResolver struct {
+	Interval time.Duration // 30 seconds by default
+}
+
+func (r *DNSResolver) Resolve(target *url.URL) (Watcher, error) {
+	name := strings.TrimPrefix(target.Path, "/")
+	if name == "" {
+		return nil, fmt.Errorf("frog: dns target %q has no name", target)
+	}
+
+	resolver := net.DefaultResolver
+	if server := target.Host; server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	interval := r.Interval
+	fetch := func() ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout(interval))
+		defer cancel()
+		_, srvs, err := resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		addrs := make([]string, 0, len(srvs))
+		for _, srv := range srvs {
+			host := strings.TrimSuffix(srv.Target, ".")
+			addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+		}
+		return addrs, nil
+	}
+	return newPollWatcher(interval, fetch), nil
+}
+
+func resolveTimeout(interval time.Duration) time.Duration {
+	if interval <= 0 || interval > 10*time.Second {
+		return 10 * time.Second
+	}
+	return interval
+}
+
+// pollWatcher calls fetch every interval and reports changed address sets.
+// Errors after the initial fetch keep the last known set.
+type pollWatcher struct {
+	interval time.Duration
+	fetch    func() ([]string, error)
+	last     []string
+	started  bool
+	once     sync.Once
+	closed   chan struct{}
+}
+
+func newPollWatcher(interval time.Duration, fetch func() ([]string, error)) *pollWatcher {
+	if interval <= 0 {
+		interval = defaultResolveInterval
+	}
+	return &pollWatcher{interval: interval, fetch: fetch, closed: make(chan struct{})}
+}
+
+func (w *pollWatcher) Next() ([]string, error) {
+	if !w.started {
+		addrs, err := w.fetch()
+		if err != nil {
+			return nil, err
+		}
+		w.started = true
+		w.last = normalizeAddrs(addrs)
+		return w.last, nil
+	}
+
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.closed:
+			return nil, ErrWatcherClosed
+		case <-t.C:
+		}
+		addrs, err := w.fetch()
+		if err != nil {
+			continue
+		}
+		addrs = normalizeAddrs(addrs)
+		if !equalAddrs(addrs, w.last) {
+			w.last = addrs
+			return addrs, nil
+		}
+	}
+}
+
+func (w *pollWatcher) Close() error {
+	w.once.Do(func() { close(w.closed) })
+	return nil
+}
+
+func normalizeAddrs(addrs []string) []string {
+	addrs = append([]string(nil), addrs...)
+	sort.Strings(addrs)
+	return addrs
+}
+
+func equalAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,314 @@
+package frog
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, and fails the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// nextAddrs calls w.Next in a goroutine, so a blocked watcher fails the test
+// instead of hanging it.
+func nextAddrs(t *testing.T, w Watcher) []string {
+	t.Helper()
+	type result struct {
+		addrs []string
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		addrs, err := w.Next()
+		ch <- result{addrs, err}
+	}()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatalf("Next failed: %v", r.err)
+		}
+		return r.addrs
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for Next")
+		return nil
+	}
+}
+
+func writeAddrs(t *testing.T, path string, content string) {
+	t.Helper()
+	// write and rename, so the watcher never reads a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStaticResolver(t *testing.T) {
+	w, err := Resolve("static:///b:2,a:1,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := nextAddrs(t, w), []string{"b:2", "a:1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("addrs = %v, want %v", got, want)
+	}
+	w.Close()
+	if _, err := w.Next(); err != ErrWatcherClosed {
+		t.Errorf("Next after Close = %v, want ErrWatcherClosed", err)
+	}
+
+	if _, err := Resolve("nope:///a:1"); err == nil {
+		t.Error("resolve target of unknown scheme succeeded")
+	}
+}
+
+func TestFileResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "echo.json")
+	writeAddrs(t, path, `["b:2", "a:1"]`)
+
+	r := &FileResolver{Interval: 10 * time.Millisecond}
+	w, err := r.Resolve(&url.URL{Scheme: "file", Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if got, want := nextAddrs(t, w), []string{"a:1", "b:2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("initial addrs = %v, want %v", got, want)
+	}
+
+	// a broken file keeps the last set, and the next rewrite is picked up
+	writeAddrs(t, path, `["a:1",`)
+	time.Sleep(30 * time.Millisecond)
+	writeAddrs(t, path, `["c:3"]`)
+	if got, want := nextAddrs(t, w), []string{"c:3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("addrs after rewrite = %v, want %v", got, want)
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		_, err := w.Next()
+		closed <- err
+	}()
+	w.Close()
+	select {
+	case err := <-closed:
+		if err != ErrWatcherClosed {
+			t.Errorf("Next after Close = %v, want ErrWatcherClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not unblock Next")
+	}
+
+	if _, err := r.Resolve(&url.URL{Scheme: "file"}); err == nil {
+		t.Error("resolve file target without path succeeded")
+	}
+	w, _ = r.Resolve(&url.URL{Scheme: "file", Path: path + ".missing"})
+	if _, err := w.Next(); err == nil {
+		t.Error("initial Next of a missing file succeeded")
+	}
+}
+
+// srvServer is a DNS server answering SRV queries over UDP with its records.
+type srvServer struct {
+	conn net.PacketConn
+
+	mu      sync.Mutex // protect following field
+	records []*net.SRV
+}
+
+func newSRVServer(t *testing.T, records ...*net.SRV) *srvServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("listen udp: %v", err)
+	}
+	s := &srvServer{conn: conn, records: records}
+	t.Cleanup(func() { conn.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *srvServer) setRecords(records ...*net.SRV) {
+	s.mu.Lock()
+	s.records = records
+	s.mu.Unlock()
+}
+
+func (s *srvServer) serve() {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp := s.answer(buf[:n]); resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+// answer builds the response to query, with an answer per record
+// for SRV questions, and no answer for others.
+func (s *srvServer) answer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	// the question is the name, type and class after the header
+	end := 12
+	for end < len(query) && query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5
+	if end > len(query) {
+		return nil
+	}
+	question := query[12:end]
+	qtype := binary.BigEndian.Uint16(question[len(question)-4:])
+
+	var records []*net.SRV
+	if qtype == 33 {
+		s.mu.Lock()
+		records = s.records
+		s.mu.Unlock()
+	}
+
+	resp := make([]byte, 12, 512)
+	copy(resp, query[:2])                        // id
+	binary.BigEndian.PutUint16(resp[2:], 0x8580) // response, authoritative, recursion
+	binary.BigEndian.PutUint16(resp[4:], 1)      // questions
+	binary.BigEndian.PutUint16(resp[6:], uint16(len(records)))
+	resp = append(resp, question...)
+	for _, r := range records {
+		target := encodeName(r.Target)
+		resp = append(resp, 0xc0, 12) // name points to the question
+		resp = binary.BigEndian.AppendUint16(resp, 33)
+		resp = binary.BigEndian.AppendUint16(resp, 1)
+		resp = binary.BigEndian.AppendUint32(resp, 0)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(6+len(target)))
+		resp = binary.BigEndian.AppendUint16(resp, r.Priority)
+		resp = binary.BigEndian.AppendUint16(resp, r.Weight)
+		resp = binary.BigEndian.AppendUint16(resp, r.Port)
+		resp = append(resp, target...)
+	}
+	return resp
+}
+
+func encodeName(name string) []byte {
+	var b []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+func TestDNSResolver(t *testing.T) {
+	s := newSRVServer(t,
+		&net.SRV{Target: "b.frog.test.", Port: 8002, Priority: 10, Weight: 1},
+		&net.SRV{Target: "a.frog.test.", Port: 8001, Priority: 10, Weight: 1},
+	)
+	r := &DNSResolver{Interval: 10 * time.Millisecond}
+	target, _ := url.Parse(fmt.Sprintf("dns://%s/_echo._tcp.frog.test.", s.conn.LocalAddr()))
+	w, err := r.Resolve(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if got, want := nextAddrs(t, w), []string{"a.frog.test:8001", "b.frog.test:8002"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("initial addrs = %v, want %v", got, want)
+	}
+
+	s.setRecords(&net.SRV{Target: "c.frog.test.", Port: 8003})
+	if got, want := nextAddrs(t, w), []string{"c.frog.test:8003"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("addrs after update = %v, want %v", got, want)
+	}
+
+	if _, err := r.Resolve(&url.URL{Scheme: "dns", Host: "127.0.0.1"}); err == nil {
+		t.Error("resolve dns target without name succeeded")
+	}
+}
+
+// closingChannel records when the balancing channel closes it.
+type closingChannel struct {
+	countingChannel
+	closed chan string
+}
+
+func (c *closingChannel) Close() error {
+	c.closed <- c.addr
+	return nil
+}
+
+func TestDialTargetFollowsResolver(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "echo.json")
+	writeAddrs(t, path, `["a:1", "b:2"]`)
+	RegisterResolver("testfile", &FileResolver{Interval: 10 * time.Millisecond})
+
+	var mu sync.Mutex
+	counts := make(map[string]int)
+	closed := make(chan string, 10)
+	dial := func(addr string) (RpcChannel, error) {
+		return &closingChannel{countingChannel{addr, &mu, counts}, closed}, nil
+	}
+	bc, err := DialTarget("testfile://"+path, dial, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bc.Backends(), []string{"a:1", "b:2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("backends = %v, want %v", got, want)
+	}
+
+	writeAddrs(t, path, `["b:2", "c:3"]`)
+	waitFor(t, "backends to follow the file", func() bool {
+		return reflect.DeepEqual(bc.Backends(), []string{"b:2", "c:3"})
+	})
+	if addr := <-closed; addr != "a:1" {
+		t.Errorf("closed sub-channel %s, want a:1", addr)
+	}
+
+	for i := 0; i < 10; i++ {
+		<-bc.Go(testService.Method(testEcho), context.Background(), nil, nil).Done()
+	}
+	mu.Lock()
+	if counts["a:1"] != 0 || counts["b:2"]+counts["c:3"] != 10 {
+		t.Errorf("calls per backend = %v, want all on b:2 and c:3", counts)
+	}
+	mu.Unlock()
+
+	// closing the channel stops the watcher and closes the sub-channels
+	bc.Close()
+	got := []string{<-closed, <-closed}
+	if got[0] > got[1] {
+		got[0], got[1] = got[1], got[0]
+	}
+	if want := []string{"b:2", "c:3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("closed sub-channels %v, want %v", got, want)
+	}
+	writeAddrs(t, path, `["d:4"]`)
+	time.Sleep(50 * time.Millisecond)
+	if backends := bc.Backends(); len(backends) != 0 {
+		t.Errorf("backends after Close = %v", backends)
+	}
+
+	if _, err := DialTarget("testfile://"+path+".missing", dial, nil); err == nil {
+		t.Error("dial target of a missing file succeeded")
+	}
+}
@@ -2,15 +2,23 @@ package frog
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sync"
+	"time"
 
-	proto "github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 var (
 	TypeOfContext = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+	typeOfError         = reflect.TypeOf((*error)(nil)).Elem()
+	typeOfMessageV1     = reflect.TypeOf((*protoadapt.MessageV1)(nil)).Elem()
+	typeOfRpcController = reflect.TypeOf((*RpcController)(nil)).Elem()
 )
 
 // RpcCall represents an RPC call
@@ -21,26 +29,93 @@ type RpcCall interface {
 	Done() chan struct{}     // Strobes when call is complete.
 }
 
+// Canceler is implemented by calls which can be abandoned before they complete.
+type Canceler interface {
+	Cancel()
+}
+
+// Cancel cancels call if it implements Canceler, and reports whether it does.
+func Cancel(call RpcCall) bool {
+	c, ok := call.(Canceler)
+	if ok {
+		c.Cancel()
+	}
+	return ok
+}
+
 // DefaultCall implements RpcCall interface and is enough to deal with most situations.
 type DefaultCall struct {
 	request  proto.Message
 	response proto.Message
-	ch       chan struct{}
 
-	mu      *sync.Mutex // protect following fields
-	err     error
-	hasDone bool // protect ch from being closed twice
+	mu       sync.Mutex    // protect following fields
+	ch       chan struct{} // created by Done, unless the call is complete
+	err      error
+	hasDone  bool          // protect ch from being closed twice
+	canceled bool          // whether the call completed by cancellation
+	onCancel []func(error) // hooks called when the call is canceled
+	stop     func() bool   // stops watching the context of the call
+	timed    bool          // whether timer is started
+	timer    WheelTimer    // timeout set by CancelAfter
+	seq      uint64        // incremented when the call is reused
+	pooled   bool          // whether the call is taken from callPool
+	refs     int           // holds of OnDone callbacks not yet called
+	released bool          // whether Release waits for the holds to end
+	free     bool          // whether the call is in callPool
+}
+
+// callHolder is implemented by calls which must not be recycled while
+// a callback of OnDone may still use them.
+type callHolder interface {
+	hold()
+	unhold()
+}
+
+// closedChan is the Done channel of calls completed before Done is called
+var closedChan = make(chan struct{})
+
+func init() {
+	close(closedChan)
+}
+
+var callPool = sync.Pool{
+	New: func() interface{} { return new(DefaultCall) },
 }
 
 func NewDefaultCall(request, response proto.Message) *DefaultCall {
-	return &DefaultCall{
-		request,
-		response,
-		make(chan struct{}),
-		new(sync.Mutex),
-		nil,
-		false,
+	return &DefaultCall{request: request, response: response}
+}
+
+// NewPooledCall is like NewDefaultCall, but takes the call from a pool, so
+// that calls do not allocate once the pool is warm. Release the call to
+// return it to the pool.
+func NewPooledCall(request, response proto.Message) *DefaultCall {
+	c := callPool.Get().(*DefaultCall)
+	c.request = request
+	c.response = response
+	c.pooled = true
+	c.free = false
+	return c
+}
+
+// NewDefaultCallWithContext returns a DefaultCall bound to ctx: if ctx is
+// done before the call completes, the call is canceled with the status of
+// ctx error. No goroutine waits for ctx.
+func NewDefaultCallWithContext(ctx context.Context, request, response proto.Message) *DefaultCall {
+	c := NewDefaultCall(request, response)
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() {
+			c.cancelIf(0, FromContextError(ctx.Err()))
+		})
+		c.mu.Lock()
+		if c.hasDone {
+			stop()
+		} else {
+			c.stop = stop
+		}
+		c.mu.Unlock()
 	}
+	return c
 }
 
 func (c *DefaultCall) Request() proto.Message {
@@ -52,23 +127,226 @@ func (c *DefaultCall) Response() proto.Message {
 }
 
 func (c *DefaultCall) Error() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.err
 }
 
 func (c *DefaultCall) Done() chan struct{} {
-	return c.ch
+	c.mu.Lock()
+	if c.ch == nil {
+		c.ch = make(chan struct{})
+	}
+	ch := c.ch
+	c.mu.Unlock()
+	return ch
 }
 
 func (c *DefaultCall) Close(err error) {
+	c.mu.Lock()
+	c.complete(err)
+	c.onCancel = nil
+	c.mu.Unlock()
+}
+
+// Cancel completes the call with a Canceled status, if it is not complete,
+// and calls the hooks registered by OnCancel.
+func (c *DefaultCall) Cancel() {
+	c.mu.Lock()
+	seq := c.seq
+	c.mu.Unlock()
+	c.cancelIf(seq, NewStatus(Canceled, "call canceled"))
+}
+
+// CancelAfter cancels the call with a DeadlineExceeded status if it does not
+// complete within d, rounded up to a tick of the timer wheel w. It replaces
+// the previous timeout of the call, if any. Hooks registered by OnCancel
+// then run on the goroutine of w.
+func (c *DefaultCall) CancelAfter(w *TimerWheel, d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hasDone {
+		return
+	}
+	if c.timed {
+		c.timer.Stop()
+	}
+	c.timed = true
+	w.start(&c.timer, d, c, c.seq)
+}
+
+// expire is called by the timer wheel when the timeout of the call expires.
+func (c *DefaultCall) expire(seq uint64) {
+	c.cancelIf(seq, NewStatus(DeadlineExceeded, "call timed out"))
+}
+
+// OnCancel registers fn to be called with the error of the call when it is
+// canceled, e.g. for the transport to abort the request. fn is called at
+// once if the call is already canceled, and never if it completes otherwise.
+// fn should not block.
+func (c *DefaultCall) OnCancel(fn func(err error)) {
 	c.mu.Lock()
 	if !c.hasDone {
-		c.hasDone = true
-		c.err = err
-		close(c.ch)
+		c.onCancel = append(c.onCancel, fn)
+		c.mu.Unlock()
+		return
 	}
+	canceled, err := c.canceled, c.err
+	c.mu.Unlock()
+	if canceled {
+		fn(err)
+	}
+}
+
+// Release returns a call created by NewPooledCall to the pool, once it is
+// complete. Neither the call nor its Done channel may be used afterwards;
+// a late Cancel is ignored while the call waits in the pool.
+// A call watched by OnDone, e.g. by a BalancingChannel, returns to the pool
+// once its callbacks have run. Release does nothing for other calls.
+func (c *DefaultCall) Release() {
+	c.mu.Lock()
+	if !c.pooled || !c.hasDone || c.released {
+		c.mu.Unlock()
+		return
+	}
+	if c.refs > 0 {
+		c.released = true
+		c.mu.Unlock()
+		return
+	}
+	c.recycle()
+}
+
+func (c *DefaultCall) hold() {
+	c.mu.Lock()
+	c.refs++
 	c.mu.Unlock()
 }
 
+// unhold ends a hold of the call, and returns it to the pool if it was
+// released while held.
+func (c *DefaultCall) unhold() {
+	c.mu.Lock()
+	if c.refs--; c.refs > 0 || !c.released {
+		c.mu.Unlock()
+		return
+	}
+	c.recycle()
+}
+
+// recycle resets the call and puts it back to callPool. c.mu must be held,
+// and is unlocked.
+func (c *DefaultCall) recycle() {
+	c.request = nil
+	c.response = nil
+	c.ch = nil
+	c.err = nil
+	c.hasDone = false
+	c.canceled = false
+	c.onCancel = nil
+	c.timer = WheelTimer{}
+	c.seq++ // ignore timeouts of the released call
+	c.pooled = false
+	c.released = false
+	c.free = true
+	c.mu.Unlock()
+	callPool.Put(c)
+}
+
+// cancelIf cancels the call with err, if it is not complete, still has seq
+// and is not in the pool.
+func (c *DefaultCall) cancelIf(seq uint64, err error) {
+	c.mu.Lock()
+	if c.hasDone || c.seq != seq || c.free {
+		c.mu.Unlock()
+		return
+	}
+	c.canceled = true
+	c.complete(err)
+	hooks := c.onCancel
+	c.onCancel = nil
+	c.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn(err)
+	}
+}
+
+// complete closes the call with err, if it is not complete, and stops
+// watching its context and timeout. c.mu must be held.
+func (c *DefaultCall) complete(err error) {
+	if c.hasDone {
+		return
+	}
+	c.hasDone = true
+	c.err = err
+	if c.ch != nil {
+		close(c.ch)
+	} else {
+		c.ch = closedChan
+	}
+	if c.stop != nil {
+		c.stop()
+		c.stop = nil
+	}
+	if c.timed {
+		c.timer.Stop()
+		c.timed = false
+	}
+}
+
+// TypedCall is a RpcCall whose response is known to be a Resp, the Go type
+// of the response message. Generated AsyncXxx stub methods return it, so
+// callers get the response without type assertions.
+type TypedCall[Resp any] struct {
+	RpcCall
+	out Resp
+}
+
+// NewTypedCall returns call typed by its response out.
+// It is called from generated code
+func NewTypedCall[Resp any](call RpcCall, out Resp) *TypedCall[Resp] {
+	return &TypedCall[Resp]{call, out}
+}
+
+// Cancel cancels the call if it implements Canceler.
+func (c *TypedCall[Resp]) Cancel() {
+	Cancel(c.RpcCall)
+}
+
+func (c *TypedCall[Resp]) hold() {
+	if h, ok := c.RpcCall.(callHolder); ok {
+		h.hold()
+	}
+}
+
+func (c *TypedCall[Resp]) unhold() {
+	if h, ok := c.RpcCall.(callHolder); ok {
+		h.unhold()
+	}
+}
+
+// Out returns the response of the call, which is complete once Done is closed.
+func (c *TypedCall[Resp]) Out() Resp {
+	return c.out
+}
+
+// Wait waits for the call to complete and returns its response, or its error.
+// If ctx is done first, it stops waiting and returns the status of ctx error;
+// the call itself goes on.
+func (c *TypedCall[Resp]) Wait(ctx context.Context) (Resp, error) {
+	var zero Resp
+	select {
+	case <-c.Done():
+		if err := c.Error(); err != nil {
+			return zero, err
+		}
+		return c.out, nil
+	case <-ctx.Done():
+		return zero, FromContextError(ctx.Err())
+	}
+}
+
 // RpcChannel represents a communication line to a Service which can
 // be used to call that Service's methods.  The Service may be running
 // on another machine.  Normally, you should not call an RpcChannel
@@ -93,11 +371,16 @@ type RpcMethod struct {
 	requestType  reflect.Type
 	responseType reflect.Type
 	method       reflect.Method
+	stream       StreamHandler
+	service      interface{}
+	handlers     *ServiceHandlers // generated handlers, nil if served by reflection
+	missing      bool             // service does not implement the method
+	async        bool             // method is served with a RpcController
 }
 
 // Name returns method name which format is "service.method"
 func (meta *RpcMethod) Name() string {
-	return meta.desc.service.GetName() + "." + meta.desc.GetName()
+	return methodName(meta.desc)
 }
 
 // Descriptor returns method descriptor
@@ -105,19 +388,60 @@ func (meta *RpcMethod) Descriptor() *MethodDesc {
 	return meta.desc
 }
 
+// IsImplemented reports whether the service implements the method.
+// Calls of unimplemented methods fail with Unimplemented.
+func (meta *RpcMethod) IsImplemented() bool {
+	return !meta.missing
+}
+
 // NewInput news a input variance
 func (meta *RpcMethod) NewRequest() proto.Message {
-	return reflect.New(meta.requestType.Elem()).Interface().(proto.Message)
+	if meta.handlers != nil && meta.handlers.NewRequest != nil {
+		return meta.handlers.NewRequest(meta.desc.index)
+	}
+	if meta.requestType == nil {
+		return newMessage(meta.desc.RequestType())
+	}
+	return protoadapt.MessageV2Of(reflect.New(meta.requestType.Elem()).Interface().(protoadapt.MessageV1))
 }
 
 // NewInput news a output variance
 func (meta *RpcMethod) NewResponse() proto.Message {
-	return reflect.New(meta.responseType.Elem()).Interface().(proto.Message)
+	if meta.handlers != nil && meta.handlers.NewResponse != nil {
+		return meta.handlers.NewResponse(meta.desc.index)
+	}
+	if meta.responseType == nil {
+		return newMessage(meta.desc.ResponseType())
+	}
+	return protoadapt.MessageV2Of(reflect.New(meta.responseType.Elem()).Interface().(protoadapt.MessageV1))
 }
 
 // CallMethod invokes meta.method with given arguments
 func CallMethod(meta *RpcMethod, ctx context.Context, request proto.Message, response proto.Message) (err error) {
-	args := []reflect.Value{meta.receiver, reflect.ValueOf(ctx), reflect.ValueOf(request), reflect.ValueOf(response)}
+	if meta.missing {
+		return Errorf(Unimplemented, "method %s not implemented", meta.Name())
+	}
+	if meta.stream != nil {
+		return Errorf(Internal, "%s is a streaming method", meta.Name())
+	}
+	if meta.async {
+		call := GoMethod(meta, ctx, request, response)
+		<-call.Done()
+		return call.Error()
+	}
+	if err := checkRequestSize(meta.desc, request); err != nil {
+		return err
+	}
+	if meta.handlers != nil && meta.handlers.Dispatch != nil {
+		return meta.handlers.Dispatch(meta.service, meta.desc.index, ctx, request, response)
+	}
+	// legacy messages are wrapped in APIv2 messages, the methods take the originals
+	args := []reflect.Value{
+		meta.receiver,
+		reflect.ValueOf(ctx),
+		reflect.ValueOf(protoadapt.MessageV1Of(request)),
+		reflect.ValueOf(protoadapt.MessageV1Of(response)),
+	}
 	retValues := meta.method.Func.Call(args)
 	if retValues[0].Interface() != nil {
 		err = retValues[0].Interface().(error)
@@ -125,18 +449,112 @@ func CallMethod(meta *RpcMethod, ctx context.Context, request proto.Message, res
 	return
 }
 
+// GoMethod invokes meta asynchronously. The returned call completes when
+// the method does: methods served with a RpcController complete when they
+// call Done, or when ctx is done first, and other methods complete before
+// GoMethod returns.
+func GoMethod(meta *RpcMethod, ctx context.Context, request proto.Message, response proto.Message) RpcCall {
+	if !meta.async {
+		call := NewDefaultCall(request, response)
+		call.Close(CallMethod(meta, ctx, request, response))
+		return call
+	}
+
+	call := NewDefaultCallWithContext(ctx, request, response)
+	if err := checkRequestSize(meta.desc, request); err != nil {
+		call.Close(err)
+		return call
+	}
+	ctrl := &serverController{ctx, call}
+	if meta.handlers != nil && meta.handlers.AsyncDispatch != nil {
+		meta.handlers.AsyncDispatch(meta.service, meta.desc.index, ctrl, request, response)
+		return call
+	}
+	args := []reflect.Value{
+		meta.receiver,
+		reflect.ValueOf(ctrl),
+		reflect.ValueOf(protoadapt.MessageV1Of(request)),
+		reflect.ValueOf(protoadapt.MessageV1Of(response)),
+	}
+	meta.method.Func.Call(args)
+	return call
+}
+
+// CallStreamMethod serves the streaming method meta on stream
+func CallStreamMethod(meta *RpcMethod, stream ServerStream) error {
+	if meta.missing {
+		return Errorf(Unimplemented, "method %s not implemented", meta.Name())
+	}
+	if meta.stream == nil {
+		return Errorf(Internal, "%s is not a streaming method", meta.Name())
+	}
+	if meta.desc.Policy().MaxRequestBytes > 0 {
+		stream = &limitedServerStream{stream, meta.desc}
+	}
+	return meta.stream(meta.service, stream)
+}
+
+// ServiceHandlers carries the generated handlers of a service, which serve
+// its methods without reflection. Methods are identified by their index
+// in ServiceDesc.
+type ServiceHandlers struct {
+	// Dispatch invokes the unary method index of service.
+	Dispatch func(service interface{}, index int, ctx context.Context, request proto.Message, response proto.Message) error
+
+	// AsyncDispatch invokes the unary method index of service, which
+	// completes the call with ctrl.Done. It is used instead of Dispatch.
+	AsyncDispatch func(service interface{}, index int, ctrl RpcController, request proto.Message, response proto.Message)
+
+	// NewRequest and NewResponse create the request and response messages of method index.
+	NewRequest  func(index int) proto.Message
+	NewResponse func(index int) proto.Message
+
+	// Streams holds the handler of every streaming method, indexed
+	// as ServiceDesc.Method, and nil for unary methods.
+	Streams []StreamHandler
+}
+
 type MethodsRegister func([]*RpcMethod) error
 
-// RegisterService registers all rpc methods in service with given register
+// RegisterService registers all rpc methods in service with given register.
+// Go methods are matched to proto methods by the names the plugin generates,
+// and an error describes any method whose signature does not match. Unary
+// methods take a context.Context and return an error, or are served
+// asynchronously: they take a RpcController and return nothing, e.g.
+//
+//	func (s *EchoServiceImpl) Echo(ctrl frog.RpcController, in *ProtoEchoRequest, out *ProtoEchoResponse)
+//
+// Streaming methods take stream types the plugin generates for each service,
+// so reflection cannot serve them: RegisterService fails if service has any,
+// and such services are registered with the generated RegisterXxx, which
+// passes the stream handlers to RegisterServiceHandlers.
 // It is called from generated code
 func RegisterService(sd *ServiceDesc, service interface{}, register MethodsRegister) (err error) {
+	return RegisterServiceHandlers(sd, service, register, nil)
+}
+
+// RegisterServiceHandlers is like RegisterService, and serves methods with
+// the generated handlers. If handlers has a Dispatch or AsyncDispatch
+// function, service is not inspected by reflection at all.
+// It is called from generated code
+func RegisterServiceHandlers(sd *ServiceDesc, service interface{}, register MethodsRegister, handlers *ServiceHandlers) (err error) {
+	if sd == nil {
+		return errors.New("frog: register service with nil ServiceDesc")
+	}
+
+	if handlers != nil && (handlers.Dispatch != nil || handlers.AsyncDispatch != nil) {
+		return registerDispatch(sd, service, register, handlers)
+	}
+
+	// Go methods are named as the plugin names them
 	methodsMap := make(map[string]*MethodDesc, len(sd.methods))
 	for _, methDesc := range sd.methods {
-		methodsMap[methDesc.GetName()] = methDesc
+		methodsMap[goName(methDesc.GetName())] = methDesc
 	}
 
 	st := reflect.TypeOf(service)
 	rpcMeths := make([]*RpcMethod, 0, len(methodsMap))
+	implemented := make(map[*MethodDesc]bool, len(methodsMap))
 	for i := 0; i < st.NumMethod(); i++ {
 		method := st.Method(i)
 		mname := method.Name
@@ -146,30 +564,58 @@ func RegisterService(sd *ServiceDesc, service interface{}, register MethodsRegis
 			continue
 		}
 
+		if methDesc.IsStreaming() {
+			var handler StreamHandler
+			if handlers != nil && methDesc.index < len(handlers.Streams) {
+				handler = handlers.Streams[methDesc.index]
+			}
+			if handler == nil {
+				return fmt.Errorf("frog: streaming method %s has no stream handler", methDesc.FullName())
+			}
+			rpcMeths = append(rpcMeths, &RpcMethod{
+				desc:     methDesc,
+				receiver: reflect.ValueOf(service),
+				method:   method,
+				stream:   handler,
+				service:  service,
+			})
+			implemented[methDesc] = true
+			continue
+		}
+
 		// method needs four ins: receiver, context, request, response
 		if mtype.NumIn() != 4 {
-			panic(fmt.Sprintln("method has wrong number of ins:", mtype.NumIn()))
+			return methodError(service, mname, "has %d parameters, want (context.Context, *%s, *%s)",
+				mtype.NumIn()-1, methDesc.descriptor.Input().FullName(), methDesc.descriptor.Output().FullName())
 		}
 
 		recvType := mtype.In(0)
 		if recvType.Kind() != reflect.Ptr {
-			panic(fmt.Sprintln("method", mname, "receiver type not a pointer:", recvType))
+			return methodError(service, mname, "receiver type not a pointer: %v", recvType)
 		}
 
+		// methods taking a RpcController complete the call asynchronously
 		ctxType := mtype.In(1)
-		if ctxType != TypeOfContext {
-			panic(fmt.Sprintln("method", mname, "context type is not context.Context:", ctxType))
+		async := ctxType == typeOfRpcController
+		if !async && ctxType != TypeOfContext {
+			return methodError(service, mname, "context type is not context.Context or frog.RpcController: %v", ctxType)
 		}
 
-		// TODO: check if request and response are proto.Message
 		requestType := mtype.In(2)
-		if requestType.Kind() != reflect.Ptr {
-			panic(fmt.Sprintln("method", mname, "request type not a pointer:", requestType))
+		if err := checkMessageType(requestType, methDesc.descriptor.Input()); err != nil {
+			return methodError(service, mname, "request %v", err)
 		}
 
 		responseType := mtype.In(3)
-		if responseType.Kind() != reflect.Ptr {
-			panic(fmt.Sprintln("method", mname, "response type not a pointer:", responseType))
+		if err := checkMessageType(responseType, methDesc.descriptor.Output()); err != nil {
+			return methodError(service, mname, "response %v", err)
+		}
+
+		if async && mtype.NumOut() != 0 {
+			return methodError(service, mname, "taking a frog.RpcController must not return values")
+		}
+		if !async && (mtype.NumOut() != 1 || mtype.Out(0) != typeOfError) {
+			return methodError(service, mname, "must return only an error")
 		}
 
 		rpcMeth := &RpcMethod{
@@ -178,16 +624,79 @@ func RegisterService(sd *ServiceDesc, service interface{}, register MethodsRegis
 			requestType,              // request type
 			responseType,             // response type
 			method,                   // method
+			nil,                      // stream
+			service,                  // service
+			nil,                      // handlers
+			false,                    // missing
+			async,                    // async
 		}
 
 		rpcMeths = append(rpcMeths, rpcMeth)
+		implemented[methDesc] = true
 	}
 
-	// method's number must be equal len(methodsMap)
-	if len(rpcMeths) != len(methodsMap) {
-		panic("methods number not match")
+	// methods the service lacks are registered too, and fail with Unimplemented,
+	// so services built against an older proto keep working
+	for _, methDesc := range sd.methods {
+		if !implemented[methDesc] {
+			rpcMeths = append(rpcMeths, &RpcMethod{
+				desc:     methDesc,
+				receiver: reflect.ValueOf(service),
+				service:  service,
+				handlers: handlers,
+				missing:  true,
+			})
+		}
 	}
 
 	err = register(rpcMeths)
 	return
 }
+
+func methodError(service interface{}, mname string, format string, a ...interface{}) error {
+	return fmt.Errorf("frog: method %s of %T %s", mname, service, fmt.Sprintf(format, a...))
+}
+
+// checkMessageType checks that t is a pointer to the generated type of the message
+func checkMessageType(t reflect.Type, message protoreflect.MessageDescriptor) error {
+	if t.Kind() != reflect.Ptr || !t.Implements(typeOfMessageV1) {
+		return fmt.Errorf("type %v is not a proto message, want *%s", t, message.FullName())
+	}
+	m := protoadapt.MessageV2Of(reflect.New(t.Elem()).Interface().(protoadapt.MessageV1))
+	if name := m.ProtoReflect().Descriptor().FullName(); name != message.FullName() {
+		return fmt.Errorf("type %v is message %s, want %s", t, name, message.FullName())
+	}
+	return nil
+}
+
+// newMessage returns a new message of mt, nil if mt is nil
+func newMessage(mt protoreflect.MessageType) proto.Message {
+	if mt == nil {
+		return nil
+	}
+	return mt.New().Interface()
+}
+
+// registerDispatch registers the methods of service served by the generated handlers
+func registerDispatch(sd *ServiceDesc, service interface{}, register MethodsRegister, handlers *ServiceHandlers) error {
+	rpcMeths := make([]*RpcMethod, 0, len(sd.methods))
+	for _, methDesc := range sd.methods {
+		rpcMeth := &RpcMethod{
+			desc:     methDesc,
+			receiver: reflect.ValueOf(service),
+			service:  service,
+			handlers: handlers,
+			async:    handlers.AsyncDispatch != nil && !methDesc.IsStreaming(),
+		}
+		if methDesc.IsStreaming() {
+			if methDesc.index < len(handlers.Streams) {
+				rpcMeth.stream = handlers.Streams[methDesc.index]
+			}
+			if rpcMeth.stream == nil {
+				return fmt.Errorf("frog: streaming method %s has no stream handler", methDesc.FullName())
+			}
+		}
+		rpcMeths = append(rpcMeths, rpcMeth)
+	}
+	return register(rpcMeths)
+}
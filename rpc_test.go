@@ -0,0 +1,405 @@
+package frog
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestRegisterNilServiceDesc(t *testing.T) {
+	register := func([]*RpcMethod) error {
+		t.Error("methods of nil ServiceDesc registered")
+		return nil
+	}
+	dispatch := &ServiceHandlers{
+		Dispatch: func(service interface{}, index int, ctx context.Context, request proto.Message, response proto.Message) error {
+			return nil
+		},
+	}
+	for _, handlers := range []*ServiceHandlers{nil, dispatch} {
+		if err := RegisterServiceHandlers(nil, struct{}{}, register, handlers); err == nil {
+			t.Errorf("register nil ServiceDesc with handlers %v succeeded", handlers)
+		}
+	}
+}
+
+func TestPooledCallReuse(t *testing.T) {
+	w := NewTimerWheel(time.Millisecond, 16)
+	defer w.Stop()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				c := NewPooledCall(nil, nil)
+				c.CancelAfter(w, time.Duration(i%3)*time.Millisecond)
+				if i%2 == 0 {
+					go c.Close(nil)
+				}
+				<-c.Done()
+				if err := c.Error(); err != nil && ErrorCode(err) != DeadlineExceeded {
+					t.Errorf("error of call = %v", err)
+				}
+				c.Release()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPooledCallTimeout(t *testing.T) {
+	w := NewTimerWheel(time.Millisecond, 8)
+	defer w.Stop()
+
+	c := NewPooledCall(nil, nil)
+	hooked := make(chan error, 1)
+	c.OnCancel(func(err error) { hooked <- err })
+	c.CancelAfter(w, 5*time.Millisecond)
+	<-c.Done()
+	if hook := <-hooked; ErrorCode(c.Error()) != DeadlineExceeded || ErrorCode(hook) != DeadlineExceeded {
+		t.Errorf("timed out call = %v, hook got %v, want DeadlineExceeded", c.Error(), hook)
+	}
+	c.Release()
+
+	// the timeout of a released call does not cancel the next user
+	c = NewPooledCall(nil, nil)
+	c.CancelAfter(w, 5*time.Millisecond)
+	c.Close(nil)
+	c.Release()
+	next := NewPooledCall(nil, nil)
+	time.Sleep(15 * time.Millisecond)
+	if next.Error() != nil {
+		t.Errorf("next call canceled by the timeout of a released call: %v", next.Error())
+	}
+	next.Close(nil)
+	next.Release()
+}
+
+func TestCallContextCanceledAfterCompletion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewDefaultCallWithContext(ctx, nil, nil)
+	c.OnCancel(func(err error) { t.Errorf("hook of a completed call called with %v", err) })
+	c.Close(nil)
+	cancel()
+	<-c.Done()
+	if err := c.Error(); err != nil {
+		t.Errorf("completed call canceled by its ctx: %v", err)
+	}
+	c.OnCancel(func(err error) { t.Errorf("hook registered after completion called with %v", err) })
+}
+
+func TestOnCancelAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	for _, tc := range []struct {
+		name   string
+		call   *DefaultCall
+		cancel func(*DefaultCall)
+		want   Code
+	}{
+		{"Cancel", NewDefaultCall(nil, nil), (*DefaultCall).Cancel, Canceled},
+		{"ctx", NewDefaultCallWithContext(ctx, nil, nil), func(c *DefaultCall) { <-c.Done() }, DeadlineExceeded},
+	} {
+		tc.cancel(tc.call)
+		hooked := make(chan error, 1)
+		tc.call.OnCancel(func(err error) { hooked <- err })
+		select {
+		case err := <-hooked:
+			if ErrorCode(err) != tc.want {
+				t.Errorf("%s: hook got %v, want %v", tc.name, err, tc.want)
+			}
+		default:
+			t.Errorf("%s: hook registered after cancel not called", tc.name)
+		}
+	}
+}
+
+func TestCancelReleasedPooledCall(t *testing.T) {
+	c := NewPooledCall(nil, nil)
+	c.Close(nil)
+	c.Release()
+	c.Cancel()
+
+	// the next user of the call, maybe c, is not canceled
+	next := NewPooledCall(nil, nil)
+	select {
+	case <-next.Done():
+		t.Fatalf("new pooled call complete: %v", next.Error())
+	default:
+	}
+	next.Close(nil)
+	if err := next.Error(); err != nil {
+		t.Errorf("new pooled call failed with %v", err)
+	}
+	next.Release()
+}
+
+// pooledChannel fails every call with a pooled call, shortly after it is made.
+type pooledChannel struct{}
+
+func (pooledChannel) Go(method *MethodDesc, ctx context.Context, request proto.Message, response proto.Message) RpcCall {
+	call := NewPooledCall(request, response)
+	go func() {
+		time.Sleep(time.Millisecond)
+		call.Close(Errorf(Unavailable, "down"))
+	}()
+	return call
+}
+
+func TestPooledCallReleasedBeforeDoneHook(t *testing.T) {
+	p, _ := newOutlierTestPolicy(OutlierConfig{ConsecutiveErrors: -1})
+	dial := func(addr string) (RpcChannel, error) { return pooledChannel{}, nil }
+	bc, err := NewBalancingChannel(dial, p, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Close()
+
+	// calls released at once must still be seen failed by the policy
+	const calls = 200
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			call := bc.Go(testService.Method(testEcho), context.Background(), nil, nil)
+			<-call.Done()
+			call.(*DefaultCall).Release()
+		}()
+	}
+	wg.Wait()
+	waitFor(t, "done hooks", func() bool { return backendStats(p, "a").TotalRequests == calls })
+	if s := backendStats(p, "a"); s.TotalFailures != calls {
+		t.Errorf("policy saw %d of %d calls failed", s.TotalFailures, calls)
+	}
+}
+
+func BenchmarkDefaultCall(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c := NewDefaultCall(nil, nil)
+		done := c.Done()
+		c.Close(nil)
+		<-done
+	}
+}
+
+func BenchmarkPooledCall(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c := NewPooledCall(nil, nil)
+		done := c.Done()
+		c.Close(nil)
+		<-done
+		c.Release()
+	}
+}
+
+func BenchmarkPooledCallTimeout(b *testing.B) {
+	w := NewTimerWheel(time.Millisecond, 1024)
+	defer w.Stop()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c := NewPooledCall(nil, nil)
+		c.CancelAfter(w, time.Second)
+		c.Close(nil)
+		c.Release()
+	}
+}
+
+func BenchmarkContextTimeout(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		c := NewDefaultCallWithContext(ctx, nil, nil)
+		c.Close(nil)
+		cancel()
+	}
+}
+
+// streamingService implements the streaming method Upload of testService.
+type streamingService struct{}
+
+func (*streamingService) Upload(stream ServerStream) error { return nil }
+
+func TestRegisterServiceStreamingMethod(t *testing.T) {
+	register := func([]*RpcMethod) error { return nil }
+	err := RegisterService(testService, new(streamingService), register)
+	if err == nil || !strings.Contains(err.Error(), "no stream handler") {
+		t.Errorf("register streaming method by reflection = %v, want no stream handler error", err)
+	}
+
+	// the generated stream handlers serve it
+	handlers := &ServiceHandlers{Streams: []StreamHandler{
+		testUpload: func(service interface{}, stream ServerStream) error { return nil },
+		testChat:   func(service interface{}, stream ServerStream) error { return nil },
+	}}
+	if err := RegisterServiceHandlers(testService, new(streamingService), register, handlers); err != nil {
+		t.Errorf("register streaming method with handlers: %v", err)
+	}
+}
+
+// namesService implements the methods of snake case names of TestRegisterServiceNames.
+type namesService struct{}
+
+func (*namesService) GetUser(ctx context.Context, in, out *wrapperspb.StringValue) error {
+	out.Value = "get_user"
+	return nil
+}
+
+func (*namesService) GetUserV2(ctx context.Context, in, out *wrapperspb.StringValue) error {
+	out.Value = "get_user_v2"
+	return nil
+}
+
+func (*namesService) V2Get(ctx context.Context, in, out *wrapperspb.StringValue) error {
+	out.Value = "v2_get"
+	return nil
+}
+
+func TestRegisterServiceNames(t *testing.T) {
+	sd := registerServiceFile(t, "frog.test", "frog/test_names.proto", "Names", "get_user", "get_user_v2", "v2_get")
+	var methods []*RpcMethod
+	if err := RegisterService(sd, new(namesService), func(m []*RpcMethod) error { methods = m; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != sd.NumMethod() {
+		t.Fatalf("%d methods registered, want %d", len(methods), sd.NumMethod())
+	}
+	for _, m := range methods {
+		out := new(wrapperspb.StringValue)
+		if err := CallMethod(m, context.Background(), new(wrapperspb.StringValue), out); err != nil || out.Value != m.Descriptor().GetName() {
+			t.Errorf("call of %s = %q, %v, want served by its Go method", m.Descriptor().GetName(), out.Value, err)
+		}
+	}
+}
+
+type (
+	wrongRequest  struct{}
+	wrongResponse struct{}
+	notMessage    struct{}
+	wrongParams   struct{}
+	wrongContext  struct{}
+	noError       struct{}
+	asyncResult   struct{}
+	valueReceiver struct{}
+)
+
+func (*wrongRequest) Echo(ctx context.Context, in *wrapperspb.Int32Value, out *wrapperspb.StringValue) error {
+	return nil
+}
+
+func (*wrongResponse) Echo(ctx context.Context, in *wrapperspb.StringValue, out *wrapperspb.Int32Value) error {
+	return nil
+}
+
+func (*notMessage) Echo(ctx context.Context, in string, out *wrapperspb.StringValue) error {
+	return nil
+}
+
+func (*wrongParams) Echo(ctx context.Context, in *wrapperspb.StringValue) error { return nil }
+
+func (*wrongContext) Echo(ctx int, in, out *wrapperspb.StringValue) error { return nil }
+
+func (*noError) Echo(ctx context.Context, in, out *wrapperspb.StringValue) {}
+
+func (*asyncResult) Echo(ctrl RpcController, in, out *wrapperspb.StringValue) error { return nil }
+
+func (valueReceiver) Echo(ctx context.Context, in, out *wrapperspb.StringValue) error { return nil }
+
+func TestRegisterServiceErrors(t *testing.T) {
+	for _, tc := range []struct {
+		service interface{}
+		want    string
+	}{
+		{new(wrongRequest), "request type *wrapperspb.Int32Value is message google.protobuf.Int32Value, want google.protobuf.StringValue"},
+		{new(wrongResponse), "response type *wrapperspb.Int32Value is message google.protobuf.Int32Value, want google.protobuf.StringValue"},
+		{new(notMessage), "request type string is not a proto message"},
+		{new(wrongParams), "has 2 parameters"},
+		{new(wrongContext), "context type is not context.Context or frog.RpcController"},
+		{new(noError), "must return only an error"},
+		{new(asyncResult), "must not return values"},
+		{valueReceiver{}, "receiver type not a pointer"},
+	} {
+		err := RegisterService(testService, tc.service, func([]*RpcMethod) error {
+			t.Errorf("%T registered", tc.service)
+			return nil
+		})
+		if err == nil || !strings.Contains(err.Error(), tc.want) || !strings.Contains(err.Error(), "method Echo") {
+			t.Errorf("register %T = %v, want error of method Echo containing %q", tc.service, err, tc.want)
+		}
+	}
+}
+
+// echoOnly implements only the Echo method of testService.
+type echoOnly struct{}
+
+func (*echoOnly) Echo(ctx context.Context, in, out *wrapperspb.StringValue) error {
+	out.Value = in.Value
+	return nil
+}
+
+func TestRegisterServiceMissingMethods(t *testing.T) {
+	var methods []*RpcMethod
+	if err := RegisterService(testService, new(echoOnly), func(m []*RpcMethod) error { methods = m; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != testService.NumMethod() {
+		t.Fatalf("%d methods registered, want %d", len(methods), testService.NumMethod())
+	}
+	for _, m := range methods {
+		var err error
+		switch m.Descriptor().Index() {
+		case testEcho:
+			out := new(wrapperspb.StringValue)
+			if err := CallMethod(m, context.Background(), wrapperspb.String("hi"), out); err != nil || out.Value != "hi" {
+				t.Errorf("call of Echo = %q, %v, want hi", out.Value, err)
+			}
+			continue
+		case testRoute:
+			err = CallMethod(m, context.Background(), m.NewRequest(), m.NewResponse())
+		default:
+			err = CallStreamMethod(m, nil)
+		}
+		if ErrorCode(err) != Unimplemented {
+			t.Errorf("call of missing method %s = %v, want Unimplemented", m.Descriptor().GetName(), err)
+		}
+	}
+}
+
+func TestTypedCallWait(t *testing.T) {
+	in, out := wrapperspb.String("hi"), new(wrapperspb.StringValue)
+	call := NewDefaultCall(in, out)
+	typed := NewTypedCall(call, out)
+
+	// ctx done first stops waiting only
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if resp, err := typed.Wait(ctx); resp != nil || ErrorCode(err) != Canceled {
+		t.Errorf("wait with canceled ctx = %v, %v, want nil, Canceled", resp, err)
+	}
+
+	out.Value = "hello"
+	call.Close(nil)
+	var resp *wrapperspb.StringValue
+	resp, err := typed.Wait(context.Background())
+	if err != nil || resp != out || resp.Value != "hello" {
+		t.Errorf("wait = %v, %v, want the response hello", resp, err)
+	}
+	if typed.Out() != out {
+		t.Error("Out does not return the response")
+	}
+
+	failed := NewDefaultCall(in, new(wrapperspb.StringValue))
+	failed.Close(Errorf(NotFound, "no user"))
+	resp, err = NewTypedCall(failed, failed.Response().(*wrapperspb.StringValue)).Wait(context.Background())
+	if resp != nil || ErrorCode(err) != NotFound {
+		t.Errorf("wait of failed call = %v, %v, want nil, NotFound", resp, err)
+	}
+}
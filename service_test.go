@@ -0,0 +1,56 @@
+package frog
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// testService is the service called by the tests. Its messages are well-known
+// types, so the tests need no generated code.
+var testService = registerTestService("frog.test", "frog/test.proto")
+
+// methods of testService
+const (
+	testEcho = iota
+	testRoute
+	testUpload
+	testChat
+)
+
+// registerTestService registers a file of package pkg which defines testService.
+func registerTestService(pkg, filename string) *ServiceDesc {
+	method := func(name, in, out string, clientStreaming, serverStreaming bool) *descriptorpb.MethodDescriptorProto {
+		return &descriptorpb.MethodDescriptorProto{
+			Name:            proto.String(name),
+			InputType:       proto.String(in),
+			OutputType:      proto.String(out),
+			ClientStreaming: proto.Bool(clientStreaming),
+			ServerStreaming: proto.Bool(serverStreaming),
+		}
+	}
+	const text = ".google.protobuf.StringValue"
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String(filename),
+		Package:    proto.String(pkg),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/descriptor.proto", "google/protobuf/wrappers.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Test"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				method("Echo", text, text, false, false),
+				method("Route", ".google.protobuf.FileDescriptorProto", text, false, false),
+				method("Upload", text, text, true, false),
+				method("Chat", text, text, true, true),
+			},
+		}},
+	}
+	file, err := protodesc.NewFile(fd, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	GenerateServiceDescFromFile(file)
+	return ServiceDescriptor(pkg + ".Test")
+}
@@ -0,0 +1,117 @@
+package frog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code is the status code of a failed rpc. The values follow google.rpc.Code.
+type Code uint32
+
+const (
+	OK                 Code = 0
+	Canceled           Code = 1
+	Unknown            Code = 2
+	InvalidArgument    Code = 3
+	DeadlineExceeded   Code = 4
+	NotFound           Code = 5
+	AlreadyExists      Code = 6
+	PermissionDenied   Code = 7
+	ResourceExhausted  Code = 8
+	FailedPrecondition Code = 9
+	Aborted            Code = 10
+	OutOfRange         Code = 11
+	Unimplemented      Code = 12
+	Internal           Code = 13
+	Unavailable        Code = 14
+	DataLoss           Code = 15
+	Unauthenticated    Code = 16
+)
+
+var codeNames = [...]string{
+	"OK",
+	"Canceled",
+	"Unknown",
+	"InvalidArgument",
+	"DeadlineExceeded",
+	"NotFound",
+	"AlreadyExists",
+	"PermissionDenied",
+	"ResourceExhausted",
+	"FailedPrecondition",
+	"Aborted",
+	"OutOfRange",
+	"Unimplemented",
+	"Internal",
+	"Unavailable",
+	"DataLoss",
+	"Unauthenticated",
+}
+
+func (c Code) String() string {
+	if int(c) < len(codeNames) {
+		return codeNames[c]
+	}
+	return fmt.Sprintf("Code(%d)", uint32(c))
+}
+
+// Status is an error carrying a Code.
+type Status struct {
+	code    Code
+	message string
+}
+
+// NewStatus returns a status with given code and message.
+func NewStatus(code Code, message string) *Status {
+	return &Status{code, message}
+}
+
+// Errorf returns an error carrying code and the formatted message.
+func Errorf(code Code, format string, a ...interface{}) error {
+	return NewStatus(code, fmt.Sprintf(format, a...))
+}
+
+func (s *Status) Code() Code {
+	return s.code
+}
+
+func (s *Status) Message() string {
+	return s.message
+}
+
+func (s *Status) Error() string {
+	return fmt.Sprintf("frog: code = %s desc = %s", s.code, s.message)
+}
+
+// ErrorCode returns the code of err: OK for nil, the code of a Status,
+// Canceled or DeadlineExceeded for context errors and Unknown for others.
+func ErrorCode(err error) Code {
+	if err == nil {
+		return OK
+	}
+	var s *Status
+	if errors.As(err, &s) {
+		return s.code
+	}
+	switch err {
+	case context.Canceled:
+		return Canceled
+	case context.DeadlineExceeded:
+		return DeadlineExceeded
+	}
+	return Unknown
+}
+
+// FromContextError converts a context error into a Status.
+func FromContextError(err error) error {
+	switch err {
+	case nil:
+		return nil
+	case context.Canceled:
+		return NewStatus(Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return NewStatus(DeadlineExceeded, err.Error())
+	}
+	return NewStatus(Unknown, err.Error())
+}
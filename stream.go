@@ -0,0 +1,305 @@
+package frog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultStreamWindow is the default flow control window of a stream direction, in bytes.
+const DefaultStreamWindow = 64 * 1024
+
+// ClientStream is the client side of a streaming call.
+type ClientStream interface {
+	// Context returns the context of the stream.
+	Context() context.Context
+
+	// SendMsg sends a request message to the server. It returns io.EOF
+	// once the server has finished the stream, and RecvMsg then returns
+	// the error the server finished it with.
+	SendMsg(m proto.Message) error
+
+	// CloseSend closes the send direction of the stream.
+	CloseSend() error
+
+	// RecvMsg receives the next response message into m. It returns io.EOF
+	// when the server finishes the stream successfully, or the error the
+	// server finished it with, normally a *Status.
+	RecvMsg(m proto.Message) error
+}
+
+// ServerStream is the server side of a streaming call.
+type ServerStream interface {
+	// Context returns the context of the stream, which is done when the
+	// client goes away.
+	Context() context.Context
+
+	// SendMsg sends a response message to the client.
+	SendMsg(m proto.Message) error
+
+	// RecvMsg receives the next request message into m. It returns io.EOF
+	// after the client closed its send direction.
+	RecvMsg(m proto.Message) error
+}
+
+// StreamChannel is implemented by RpcChannels which support streaming methods.
+type StreamChannel interface {
+	RpcChannel
+
+	// NewStream starts a streaming call of method.
+	NewStream(method *MethodDesc, ctx context.Context) (ClientStream, error)
+}
+
+// NewStream starts a streaming call of method on channel. It is called from
+// generated code, and fails with Unimplemented if channel is not a StreamChannel.
+func NewStream(channel RpcChannel, method *MethodDesc, ctx context.Context) (ClientStream, error) {
+	sc, ok := channel.(StreamChannel)
+	if !ok {
+		return nil, Errorf(Unimplemented, "channel %T does not support streaming method %s", channel, methodName(method))
+	}
+	return sc.NewStream(method, ctx)
+}
+
+// StreamHandler serves a streaming method of service on stream.
+// It is generated for every streaming method.
+type StreamHandler func(service interface{}, stream ServerStream) error
+
+// FlowWindow is a flow control window of a stream direction. A sender
+// acquires credits for the bytes it sends, and the receiver releases them
+// once the bytes are consumed, so a fast sender can not have more than
+// the window size in flight.
+type FlowWindow struct {
+	size int
+
+	mu     sync.Mutex // protect following fields
+	avail  int
+	freed  chan struct{} // closed and replaced on every release, until the window is closed
+	closed bool
+}
+
+func NewFlowWindow(size int) *FlowWindow {
+	if size <= 0 {
+		size = DefaultStreamWindow
+	}
+	return &FlowWindow{size: size, avail: size, freed: make(chan struct{})}
+}
+
+// credits returns the credits taken by n bytes. A message larger than the
+// window takes the whole window, so it is sent alone.
+func (w *FlowWindow) credits(n int) int {
+	if n > w.size {
+		return w.size
+	}
+	return n
+}
+
+// Acquire blocks until there are credits for n bytes or ctx is done.
+// It fails without waiting once the receiver of the stream is gone.
+func (w *FlowWindow) Acquire(ctx context.Context, n int) error {
+	n = w.credits(n)
+	for {
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			return errWindowClosed
+		}
+		if w.avail >= n {
+			w.avail -= n
+			w.mu.Unlock()
+			return nil
+		}
+		freed := w.freed
+		w.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return FromContextError(ctx.Err())
+		}
+	}
+}
+
+// Release gives back the credits of n consumed bytes.
+func (w *FlowWindow) Release(n int) {
+	w.mu.Lock()
+	w.avail += w.credits(n)
+	if !w.closed {
+		close(w.freed)
+		w.freed = make(chan struct{})
+	}
+	w.mu.Unlock()
+}
+
+// close wakes the senders blocked in Acquire, which fail as later calls do.
+func (w *FlowWindow) close() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.freed)
+	}
+	w.mu.Unlock()
+}
+
+var (
+	errQueueClosed  = errors.New("frog: stream queue closed")
+	errWindowClosed = errors.New("frog: flow window closed")
+)
+
+// msgQueue carries encoded messages of one stream direction within a flow window.
+type msgQueue struct {
+	window *FlowWindow
+
+	mu     sync.Mutex // protect following fields
+	items  [][]byte
+	closed bool
+	err    error         // reported to the receiver after the queued messages
+	ready  chan struct{} // strobes when a message is queued or the queue closed
+}
+
+func newMsgQueue(window int) *msgQueue {
+	return &msgQueue{window: NewFlowWindow(window), ready: make(chan struct{}, 1)}
+}
+
+func (q *msgQueue) push(ctx context.Context, m proto.Message) error {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return Errorf(Internal, "marshal message failed: %v", err)
+	}
+	if err := q.window.Acquire(ctx, len(b)); err != nil {
+		if err == errWindowClosed {
+			return errQueueClosed
+		}
+		return err
+	}
+
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		q.window.Release(len(b))
+		return errQueueClosed
+	}
+	q.items = append(q.items, b)
+	q.mu.Unlock()
+	q.signal()
+	return nil
+}
+
+func (q *msgQueue) pop(ctx context.Context, m proto.Message) error {
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			b := q.items[0]
+			q.items[0] = nil
+			q.items = q.items[1:]
+			q.mu.Unlock()
+			q.window.Release(len(b))
+			if err := proto.Unmarshal(b, m); err != nil {
+				return Errorf(Internal, "unmarshal message failed: %v", err)
+			}
+			return nil
+		}
+		if q.closed {
+			err := q.err
+			q.mu.Unlock()
+			return err
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return FromContextError(ctx.Err())
+		}
+	}
+}
+
+// close stops the queue, and the receiver gets err after the queued messages.
+// Senders waiting for the window fail with errQueueClosed.
+func (q *msgQueue) close(err error) {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		q.err = err
+	}
+	q.mu.Unlock()
+	q.window.close()
+	q.signal()
+}
+
+func (q *msgQueue) signal() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+type pipeClientStream struct {
+	ctx  context.Context
+	up   *msgQueue
+	down *msgQueue
+}
+
+func (s *pipeClientStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *pipeClientStream) SendMsg(m proto.Message) error {
+	err := s.up.push(s.ctx, m)
+	if err == errQueueClosed {
+		// the server has finished or the send direction was closed
+		return io.EOF
+	}
+	return err
+}
+
+func (s *pipeClientStream) CloseSend() error {
+	s.up.close(io.EOF)
+	return nil
+}
+
+func (s *pipeClientStream) RecvMsg(m proto.Message) error {
+	return s.down.pop(s.ctx, m)
+}
+
+type pipeServerStream struct {
+	ctx  context.Context
+	up   *msgQueue
+	down *msgQueue
+}
+
+func (s *pipeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *pipeServerStream) SendMsg(m proto.Message) error {
+	return s.down.push(s.ctx, m)
+}
+
+func (s *pipeServerStream) RecvMsg(m proto.Message) error {
+	return s.up.pop(s.ctx, m)
+}
+
+// ServeStream serves the streaming method meta in a new goroutine and
+// returns the client side of the stream, for RpcChannels calling services
+// in the same process. Each direction holds at most window bytes of
+// messages, DefaultStreamWindow if window is not positive, and blocks the
+// sender beyond that. Canceling ctx cancels the server side too.
+func ServeStream(meta *RpcMethod, ctx context.Context, window int) ClientStream {
+	up := newMsgQueue(window)
+	down := newMsgQueue(window)
+	sctx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		defer cancel()
+		err := CallStreamMethod(meta, &pipeServerStream{sctx, up, down})
+		if err == nil {
+			err = io.EOF
+		}
+		down.close(err)
+		up.close(io.EOF)
+	}()
+	return &pipeClientStream{ctx, up, down}
+}
@@ -0,0 +1,178 @@
+package frog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func unimplementedStream(service interface{}, stream ServerStream) error {
+	return Errorf(Unimplemented, "no handler")
+}
+
+// streamMethods registers testService with handlers of its streaming methods,
+// nil for methods the test does not call.
+func streamMethods(t *testing.T, upload, chat StreamHandler) (uploadMethod, chatMethod *RpcMethod) {
+	t.Helper()
+	if upload == nil {
+		upload = unimplementedStream
+	}
+	if chat == nil {
+		chat = unimplementedStream
+	}
+	handlers := &ServiceHandlers{
+		Dispatch: func(service interface{}, index int, ctx context.Context, request proto.Message, response proto.Message) error {
+			return Errorf(Unimplemented, "unary method %d", index)
+		},
+		Streams: []StreamHandler{testUpload: upload, testChat: chat},
+	}
+	var methods []*RpcMethod
+	err := RegisterServiceHandlers(testService, nil, func(m []*RpcMethod) error { methods = m; return nil }, handlers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return methods[testUpload], methods[testChat]
+}
+
+// inTime runs f and fails the test if f does not return within a few seconds.
+func inTime(t *testing.T, what string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s blocked", what)
+	}
+}
+
+func TestStreamFlowControl(t *testing.T) {
+	received := make(chan int, 1)
+	upload := func(service interface{}, stream ServerStream) error {
+		n := 0
+		for {
+			var in wrapperspb.StringValue
+			err := stream.RecvMsg(&in)
+			if err == io.EOF {
+				received <- n
+				return stream.SendMsg(wrapperspb.String(fmt.Sprint(n)))
+			}
+			if err != nil {
+				return err
+			}
+			n++
+			time.Sleep(time.Millisecond)
+		}
+	}
+	uploadMethod, _ := streamMethods(t, upload, nil)
+
+	// each message takes 12 bytes, so 5 fit in the window
+	cs := ServeStream(uploadMethod, context.Background(), 64)
+	start := time.Now()
+	inTime(t, "upload", func() {
+		for i := 0; i < 20; i++ {
+			if err := cs.SendMsg(wrapperspb.String("0123456789")); err != nil {
+				t.Errorf("send %d failed: %v", i, err)
+				return
+			}
+		}
+		cs.CloseSend()
+		var out wrapperspb.StringValue
+		if err := cs.RecvMsg(&out); err != nil || out.Value != "20" {
+			t.Errorf("response = %q, %v, want 20", out.Value, err)
+		}
+		if err := cs.RecvMsg(&out); err != io.EOF {
+			t.Errorf("end of stream = %v, want io.EOF", err)
+		}
+	})
+	// the sender waited for the slow receiver
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("20 messages sent in %v, faster than received", elapsed)
+	}
+	if n := <-received; n != 20 {
+		t.Errorf("server received %d messages, want 20", n)
+	}
+}
+
+func TestStreamServerFinishesEarly(t *testing.T) {
+	upload := func(service interface{}, stream ServerStream) error {
+		// give the client time to fill the window
+		time.Sleep(20 * time.Millisecond)
+		return Errorf(InvalidArgument, "rejected")
+	}
+	uploadMethod, _ := streamMethods(t, upload, nil)
+
+	// the client has no deadline, so only the end of the server unblocks it
+	cs := ServeStream(uploadMethod, context.Background(), 64)
+	inTime(t, "send to a finished server", func() {
+		sent := 0
+		var err error
+		for ; sent < 100; sent++ {
+			if err = cs.SendMsg(wrapperspb.String("0123456789")); err != nil {
+				break
+			}
+		}
+		if err != io.EOF || sent < 5 {
+			t.Errorf("send failed with %v after %d messages, want io.EOF after a full window", err, sent)
+		}
+		var out wrapperspb.StringValue
+		if err := cs.RecvMsg(&out); ErrorCode(err) != InvalidArgument {
+			t.Errorf("receive = %v, want the error of the server", err)
+		}
+	})
+}
+
+func TestStreamBidirectional(t *testing.T) {
+	chat := func(service interface{}, stream ServerStream) error {
+		for {
+			var in wrapperspb.StringValue
+			err := stream.RecvMsg(&in)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := stream.SendMsg(&in); err != nil {
+				return err
+			}
+		}
+	}
+	_, chatMethod := streamMethods(t, nil, chat)
+
+	inTime(t, "chat", func() {
+		cs := ServeStream(chatMethod, context.Background(), 0)
+		for i := 0; i < 5; i++ {
+			cs.SendMsg(wrapperspb.String(fmt.Sprint(i)))
+			var out wrapperspb.StringValue
+			if err := cs.RecvMsg(&out); err != nil || out.Value != fmt.Sprint(i) {
+				t.Errorf("echo %d = %q, %v", i, out.Value, err)
+				return
+			}
+		}
+		cs.CloseSend()
+		if err := cs.SendMsg(wrapperspb.String("late")); err != io.EOF {
+			t.Errorf("send after CloseSend = %v, want io.EOF", err)
+		}
+		var out wrapperspb.StringValue
+		if err := cs.RecvMsg(&out); err != io.EOF {
+			t.Errorf("end of stream = %v, want io.EOF", err)
+		}
+
+		// canceling the client cancels the server
+		ctx, cancel := context.WithCancel(context.Background())
+		cs = ServeStream(chatMethod, ctx, 0)
+		cancel()
+		if err := cs.RecvMsg(&out); ErrorCode(err) != Canceled {
+			t.Errorf("receive after cancel = %v, want Canceled", err)
+		}
+	})
+}
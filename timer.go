@@ -0,0 +1,173 @@
+package frog
+
+import (
+	"sync"
+	"time"
+)
+
+// TimerWheel schedules many timeouts, e.g. of all calls on a channel, with
+// one goroutine and a precision of one tick, instead of a runtime timer or
+// a goroutine per timeout.
+type TimerWheel struct {
+	tick  time.Duration
+	stopc chan struct{}
+
+	mu      sync.Mutex // protect following fields
+	slots   []*WheelTimer
+	pos     int
+	stopped bool
+}
+
+// WheelTimer is a timer of a TimerWheel.
+type WheelTimer struct {
+	wheel      *TimerWheel
+	prev, next *WheelTimer // in the list of slot, while the timer is pending
+	slot       int
+	rounds     int // turns of the wheel before the timer expires
+	pending    bool
+	task       timerTask
+	seq        uint64
+}
+
+// timerTask is run when a timer expires, with the seq of the timer.
+type timerTask interface {
+	expire(seq uint64)
+}
+
+type funcTask func()
+
+func (f funcTask) expire(uint64) { f() }
+
+type expiredTimer struct {
+	task timerTask
+	seq  uint64
+}
+
+// NewTimerWheel returns a TimerWheel of the given number of slots, which
+// advances by one slot every tick. Timeouts up to tick*slots cost the same.
+func NewTimerWheel(tick time.Duration, slots int) *TimerWheel {
+	if slots <= 0 {
+		slots = 1
+	}
+	w := &TimerWheel{
+		tick:  tick,
+		stopc: make(chan struct{}),
+		slots: make([]*WheelTimer, slots),
+	}
+	go w.run()
+	return w
+}
+
+// AfterFunc calls f in its own goroutine after d, rounded up to a tick,
+// unless the returned timer is stopped.
+func (w *TimerWheel) AfterFunc(d time.Duration, f func()) *WheelTimer {
+	t := new(WheelTimer)
+	w.start(t, d, funcTask(func() { go f() }), 0)
+	return t
+}
+
+// Stop stops the wheel. Pending timers never expire.
+func (w *TimerWheel) Stop() {
+	w.mu.Lock()
+	if !w.stopped {
+		w.stopped = true
+		close(w.stopc)
+	}
+	w.mu.Unlock()
+}
+
+// start schedules t, which is not pending, to run task with seq after d.
+func (w *TimerWheel) start(t *WheelTimer, d time.Duration, task timerTask, seq uint64) {
+	ticks := int((d + w.tick - 1) / w.tick)
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	t.wheel = w
+	t.slot = (w.pos + ticks) % len(w.slots)
+	t.rounds = (ticks - 1) / len(w.slots)
+	t.task = task
+	t.seq = seq
+	t.pending = true
+	t.prev = nil
+	t.next = w.slots[t.slot]
+	if t.next != nil {
+		t.next.prev = t
+	}
+	w.slots[t.slot] = t
+}
+
+// remove unlinks the pending timer t. w.mu must be held.
+func (w *TimerWheel) remove(t *WheelTimer) {
+	if t.prev != nil {
+		t.prev.next = t.next
+	} else {
+		w.slots[t.slot] = t.next
+	}
+	if t.next != nil {
+		t.next.prev = t.prev
+	}
+	t.prev, t.next = nil, nil
+	t.pending = false
+}
+
+func (w *TimerWheel) run() {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	var expired []expiredTimer
+	for {
+		select {
+		case <-ticker.C:
+			expired = w.advance(expired)
+		case <-w.stopc:
+			return
+		}
+	}
+}
+
+// advance moves the wheel to its next slot and runs the expired timers,
+// collected into the reused buffer expired.
+func (w *TimerWheel) advance(expired []expiredTimer) []expiredTimer {
+	w.mu.Lock()
+	w.pos = (w.pos + 1) % len(w.slots)
+	expired = expired[:0]
+	for t := w.slots[w.pos]; t != nil; {
+		next := t.next
+		if t.rounds > 0 {
+			t.rounds--
+		} else {
+			w.remove(t)
+			expired = append(expired, expiredTimer{t.task, t.seq})
+			t.task = nil
+		}
+		t = next
+	}
+	w.mu.Unlock()
+
+	// tasks run without the lock, as they may start timers
+	for i, e := range expired {
+		e.task.expire(e.seq)
+		expired[i] = expiredTimer{}
+	}
+	return expired
+}
+
+// Stop prevents the timer from expiring. It returns false if the timer has
+// already expired or been stopped.
+// Stop must not be called concurrently with the function starting the timer.
+func (t *WheelTimer) Stop() bool {
+	w := t.wheel
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !t.pending {
+		return false
+	}
+	w.remove(t)
+	t.task = nil
+	return true
+}
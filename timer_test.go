@@ -0,0 +1,51 @@
+package frog
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTimerWheel(t *testing.T) {
+	w := NewTimerWheel(time.Millisecond, 8)
+	defer w.Stop()
+
+	// timeouts longer than a turn of the wheel wait for more turns
+	var mu sync.Mutex
+	fired := make(map[time.Duration]time.Duration)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, d := range []time.Duration{time.Millisecond, 5 * time.Millisecond, 9 * time.Millisecond, 20 * time.Millisecond} {
+		d := d
+		wg.Add(1)
+		w.AfterFunc(d, func() {
+			mu.Lock()
+			fired[d] = time.Since(start)
+			mu.Unlock()
+			wg.Done()
+		})
+	}
+	stopped := w.AfterFunc(3*time.Millisecond, func() { t.Error("stopped timer fired") })
+	if !stopped.Stop() {
+		t.Error("Stop of a pending timer reported false")
+	}
+	if stopped.Stop() {
+		t.Error("second Stop reported true")
+	}
+	wg.Wait()
+	for d, after := range fired {
+		if after < d {
+			t.Errorf("timer of %v fired after %v", d, after)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+}
+
+func BenchmarkTimerWheel(b *testing.B) {
+	w := NewTimerWheel(time.Millisecond, 1024)
+	defer w.Stop()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		w.AfterFunc(time.Second, func() {}).Stop()
+	}
+}
@@ -4,3 +4,56 @@ package frog
 func GenerateServiceDescName(servName string) string {
 	return servName + "_ServiceDesc"
 }
+
+// GenerateFullMethodNameConst generates the name of the constant holding the
+// full name of a method, e.g. "EchoService_Echo_FullMethodName"
+func GenerateFullMethodNameConst(servName, methName string) string {
+	return servName + "_" + methName + "_FullMethodName"
+}
+
+// GenerateMethodDescName generates the name of the function returning the
+// descriptor of a method, e.g. "EchoService_Echo_MethodDesc"
+func GenerateMethodDescName(servName, methName string) string {
+	return servName + "_" + methName + "_MethodDesc"
+}
+
+// methodName returns method name which format is "service.method"
+func methodName(md *MethodDesc) string {
+	return md.service.GetName() + "." + md.GetName()
+}
+
+// goName returns the Go name of a proto method or service as generated
+// by the plugin, e.g. "GetUser" for "get_user"
+func goName(name string) string {
+	var b []byte
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case c == '.' && i+1 < len(name) && isLower(name[i+1]):
+			// skip '.' in ".{{lowercase}}"
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || name[i-1] == '.'):
+			// an initial '_' becomes 'X' so the name is exported
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(name) && isLower(name[i+1]):
+			// skip '_' in "_{{lowercase}}"
+		case '0' <= c && c <= '9':
+			b = append(b, c)
+		default:
+			// a word starts, in upper case, and takes the lower case letters following
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(name) && isLower(name[i+1]); i++ {
+				b = append(b, name[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isLower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
@@ -0,0 +1,25 @@
+package frog
+
+import "testing"
+
+func TestGoName(t *testing.T) {
+	for _, tc := range []struct {
+		name, want string
+	}{
+		{"Echo", "Echo"},
+		{"echo", "Echo"},
+		{"get_user", "GetUser"},
+		{"GetUser", "GetUser"},
+		{"HTTPGet", "HTTPGet"},
+		{"get_user_v2", "GetUserV2"},
+		{"v2_get", "V2Get"},
+		{"get2user", "Get2User"},
+		{"get_2fa", "Get_2Fa"},
+		{"get__user", "Get_User"},
+		{"_private", "XPrivate"},
+	} {
+		if got := goName(tc.name); got != tc.want {
+			t.Errorf("goName(%q) = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
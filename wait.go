@@ -0,0 +1,213 @@
+package frog
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// CallErrors holds the errors of calls waited together, indexed like the
+// calls. The error of a call which succeeded, or was not waited for, is nil.
+type CallErrors []error
+
+func (e CallErrors) Error() string {
+	var failed []string
+	for i, err := range e {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("call %d: %v", i, err))
+		}
+	}
+	return fmt.Sprintf("frog: %d of %d calls failed: %s", len(failed), len(e), strings.Join(failed, "; "))
+}
+
+// Unwrap returns the errors of the failed calls, for errors.Is and errors.As.
+func (e CallErrors) Unwrap() []error {
+	var errs []error
+	for _, err := range e {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// WaitAll waits for all calls to complete. It returns nil if they all
+// succeeded, and otherwise CallErrors holding the error of each failed call.
+// If ctx is done first, WaitAll stops waiting and the error of each call
+// still pending is the status of ctx error.
+func WaitAll(ctx context.Context, calls ...RpcCall) error {
+	_, err := WaitN(ctx, len(calls), calls...)
+	return err
+}
+
+// WaitN waits for n of the calls to complete, and returns their indexes in
+// order of completion. The error is nil if they all succeeded, and otherwise
+// CallErrors as returned by WaitAll; calls completed after the first n are
+// not waited for. WaitN does not start any goroutine.
+func WaitN(ctx context.Context, n int, calls ...RpcCall) ([]int, error) {
+	if n > len(calls) {
+		n = len(calls)
+	} else if n < 0 {
+		n = 0
+	}
+	done := make([]int, 0, n)
+	errs := make(CallErrors, len(calls))
+	failed := false
+
+	// cases[0] is ctx, cases[i+1] the i-th call until it completes
+	cases := make([]reflect.SelectCase, len(calls)+1)
+	cases[0] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	for i, call := range calls {
+		cases[i+1] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(call.Done())}
+	}
+	for len(done) < n {
+		chosen, _, _ := reflect.Select(cases)
+		if chosen == 0 {
+			err := FromContextError(ctx.Err())
+			for i := range calls {
+				if cases[i+1].Chan.IsValid() {
+					errs[i] = err
+				}
+			}
+			return done, errs
+		}
+		i := chosen - 1
+		cases[chosen].Chan = reflect.Value{}
+		done = append(done, i)
+		if errs[i] = calls[i].Error(); errs[i] != nil {
+			failed = true
+		}
+	}
+	if failed {
+		return done, errs
+	}
+	return done, nil
+}
+
+// WaitAny waits for one of the calls to complete, and returns its index and
+// error. If ctx is done first, it returns -1 and the status of ctx error.
+// It returns -1 and nil if there is no call.
+func WaitAny(ctx context.Context, calls ...RpcCall) (int, error) {
+	if len(calls) == 0 {
+		return -1, nil
+	}
+	done, err := WaitN(ctx, 1, calls...)
+	if len(done) == 0 {
+		return -1, FromContextError(ctx.Err())
+	}
+	if err != nil {
+		err = err.(CallErrors)[done[0]]
+	}
+	return done[0], err
+}
+
+// OnDone calls fn with call once call completes. fn runs on the calling
+// goroutine if call is already complete, and otherwise on a goroutine shared
+// with the callbacks of other calls, so fn should not block. A pooled call
+// is not recycled by Release before fn has run.
+func OnDone(call RpcCall, fn func(RpcCall)) {
+	// the channel is taken now, as a released call may return another one
+	done := call.Done()
+	select {
+	case <-done:
+		fn(call)
+		return
+	default:
+	}
+	if h, ok := call.(callHolder); ok {
+		h.hold()
+	}
+	addCallback(callback{call, done, fn})
+}
+
+// watcherCalls is the maximum number of calls watched by a goroutine
+const watcherCalls = 64
+
+type callback struct {
+	call RpcCall
+	done <-chan struct{} // Done of call when OnDone was called
+	fn   func(RpcCall)
+}
+
+// callWatcher is a goroutine calling the callbacks of up to watcherCalls calls.
+type callWatcher struct {
+	add     chan callback
+	pending int // callbacks added and not called, protected by watchersMu
+}
+
+var (
+	watchersMu sync.Mutex
+	watchers   []*callWatcher // running watchers
+)
+
+// addCallback hands cb to a running watcher with room for it, or a new one.
+func addCallback(cb callback) {
+	watchersMu.Lock()
+	var w *callWatcher
+	for _, running := range watchers {
+		if running.pending < watcherCalls {
+			w = running
+			break
+		}
+	}
+	if w == nil {
+		w = &callWatcher{add: make(chan callback, watcherCalls)}
+		watchers = append(watchers, w)
+		go w.run()
+	}
+	w.pending++
+	watchersMu.Unlock()
+
+	// never blocks, as the watcher has room for pending callbacks
+	w.add <- cb
+}
+
+// done records that a callback of w has been called, and reports whether
+// w has no more callbacks, in which case it is stopped.
+func (w *callWatcher) done() bool {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+	if w.pending--; w.pending > 0 {
+		return false
+	}
+	for i, running := range watchers {
+		if running == w {
+			watchers = append(watchers[:i], watchers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+func (w *callWatcher) run() {
+	// cases[0] receives callbacks, cases[i+1] is the call of callbacks[i]
+	cases := []reflect.SelectCase{{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w.add)}}
+	var callbacks []callback
+	for {
+		chosen, recv, _ := reflect.Select(cases)
+		if chosen == 0 {
+			cb := recv.Interface().(callback)
+			callbacks = append(callbacks, cb)
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(cb.done)})
+			continue
+		}
+
+		cb := callbacks[chosen-1]
+		last := len(callbacks) - 1
+		callbacks[chosen-1], callbacks[last] = callbacks[last], callback{}
+		callbacks = callbacks[:last]
+		cases[chosen], cases[last+1] = cases[last+1], reflect.SelectCase{}
+		cases = cases[:last+1]
+
+		stop := w.done()
+		cb.fn(cb.call)
+		if h, ok := cb.call.(callHolder); ok {
+			h.unhold()
+		}
+		if stop {
+			return
+		}
+	}
+}
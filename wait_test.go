@@ -0,0 +1,130 @@
+package frog
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWaitAll(t *testing.T) {
+	a, b, c := NewDefaultCall(nil, nil), NewDefaultCall(nil, nil), NewDefaultCall(nil, nil)
+	go func() {
+		a.Close(nil)
+		b.Close(Errorf(Unavailable, "down"))
+		c.Close(nil)
+	}()
+	err := WaitAll(context.Background(), a, b, c)
+	var errs CallErrors
+	if !errors.As(err, &errs) || errs[0] != nil || ErrorCode(errs[1]) != Unavailable || errs[2] != nil {
+		t.Fatalf("WaitAll = %v, want the error of the second call", err)
+	}
+	if err := WaitAll(context.Background(), a, c); err != nil {
+		t.Errorf("WaitAll of succeeded calls = %v", err)
+	}
+
+	// calls still pending when ctx is done fail with its status
+	pending := NewDefaultCall(nil, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = WaitAll(ctx, a, pending)
+	if errs, ok := err.(CallErrors); !ok || errs[0] != nil || ErrorCode(errs[1]) != DeadlineExceeded {
+		t.Errorf("WaitAll after deadline = %v", err)
+	}
+}
+
+func TestWaitAnyAndN(t *testing.T) {
+	pending, failed, ok := NewDefaultCall(nil, nil), NewDefaultCall(nil, nil), NewDefaultCall(nil, nil)
+	failed.Close(Errorf(Unavailable, "down"))
+	ok.Close(nil)
+
+	if i, err := WaitAny(context.Background(), pending, failed); i != 1 || ErrorCode(err) != Unavailable {
+		t.Errorf("WaitAny = %d, %v, want 1, Unavailable", i, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if i, err := WaitAny(ctx, pending); i != -1 || ErrorCode(err) != Canceled {
+		t.Errorf("WaitAny of canceled ctx = %d, %v, want -1, Canceled", i, err)
+	}
+	if i, err := WaitAny(context.Background()); i != -1 || err != nil {
+		t.Errorf("WaitAny without calls = %d, %v", i, err)
+	}
+
+	done, err := WaitN(context.Background(), 1, pending, ok, failed)
+	if len(done) != 1 || done[0] == 0 {
+		t.Errorf("WaitN(1) = %v, %v", done, err)
+	}
+	done, err = WaitN(context.Background(), 2, pending, ok, failed)
+	if len(done) != 2 || err == nil {
+		t.Errorf("WaitN(2) = %v, %v, want both complete calls and an error", done, err)
+	}
+}
+
+func TestOnDone(t *testing.T) {
+	const calls = 1000
+	base := runtime.NumGoroutine()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	called := 0
+	pending := make([]*DefaultCall, calls)
+	for i := range pending {
+		pending[i] = NewDefaultCall(nil, nil)
+		wg.Add(1)
+		OnDone(pending[i], func(RpcCall) {
+			mu.Lock()
+			called++
+			mu.Unlock()
+			wg.Done()
+		})
+	}
+	// a goroutine watches many calls
+	if n := runtime.NumGoroutine() - base; n > calls/watcherCalls+1 {
+		t.Errorf("%d goroutines watch %d calls", n, calls)
+	}
+	for _, c := range pending {
+		c.Close(nil)
+	}
+	wg.Wait()
+	if called != calls {
+		t.Errorf("%d callbacks called, want %d", called, calls)
+	}
+	waitFor(t, "watchers to stop", func() bool { return runtime.NumGoroutine() <= base })
+
+	// the callback of a complete call runs at once
+	ran := false
+	OnDone(pending[0], func(RpcCall) { ran = true })
+	if !ran {
+		t.Error("callback of a complete call did not run at once")
+	}
+}
+
+// reusedCall returns a new channel from Done once it has been called, like
+// a pooled call released and taken again.
+type reusedCall struct {
+	*DefaultCall
+	mu    sync.Mutex
+	calls int
+}
+
+func (c *reusedCall) Done() chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls++; c.calls > 1 {
+		return make(chan struct{})
+	}
+	return c.DefaultCall.Done()
+}
+
+func TestOnDoneWatchesDoneOfCall(t *testing.T) {
+	call := &reusedCall{DefaultCall: NewDefaultCall(nil, nil)}
+	called := make(chan struct{})
+	OnDone(call, func(RpcCall) { close(called) })
+	call.Close(nil)
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not called once the call completed")
+	}
+}